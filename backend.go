@@ -0,0 +1,128 @@
+package graphos
+
+import "io"
+
+// VideoBackend opens the output and drives the game loop. Run must call
+// i.Update once per tick and i.Present whenever it is ready to show a
+// frame, until the loop ends.
+type VideoBackend interface {
+	Run(i *Instance) error
+}
+
+// backend is a video, input and audio backend in one, such as the
+// default one New installs.
+type backend interface {
+	VideoBackend
+	InputBackend
+	AudioBackend
+}
+
+// InputBackend reports the state of the keyboard and the mouse.
+type InputBackend interface {
+	IsKeyPressed(key Key) bool
+	IsKeyJustPressed(key Key) bool
+	IsKeyJustReleased(key Key) bool
+	IsMouseButtonPressed(button MouseButton) bool
+	IsMouseButtonJustPressed(button MouseButton) bool
+	IsMouseButtonJustReleased(button MouseButton) bool
+	CursorPosition() (x, y int)
+	AppendInputChars(runes []rune) []rune
+}
+
+// AudioBackend plays a looping sound.
+type AudioBackend interface {
+	LoadWavLoop(r io.Reader) error
+	Play()
+	Pause()
+}
+
+type Key int
+
+const (
+	KeyA Key = iota
+	KeyB
+	KeyC
+	KeyD
+	KeyE
+	KeyF
+	KeyG
+	KeyH
+	KeyI
+	KeyJ
+	KeyK
+	KeyL
+	KeyM
+	KeyN
+	KeyO
+	KeyP
+	KeyQ
+	KeyR
+	KeyS
+	KeyT
+	KeyU
+	KeyV
+	KeyW
+	KeyX
+	KeyY
+	KeyZ
+	Key0
+	Key1
+	Key2
+	Key3
+	Key4
+	Key5
+	Key6
+	Key7
+	Key8
+	Key9
+	KeySpace
+	KeyComma
+	KeyPeriod
+	KeyMinus
+	KeyEqual
+	KeyLeftBracket
+	KeyRightBracket
+	KeyBackslash
+	KeySemicolon
+	KeyApostrophe
+	KeySlash
+	KeyGraveAccent
+	KeyEnter
+	KeyBackspace
+	KeyTab
+	KeyEscape
+	KeyShift
+	KeyControl
+	KeyAlt
+	KeyUp
+	KeyDown
+	KeyLeft
+	KeyRight
+	KeyHome
+	KeyEnd
+	KeyPageUp
+	KeyPageDown
+	KeyInsert
+	KeyDelete
+	KeyF1
+	KeyF2
+	KeyF3
+	KeyF4
+	KeyF5
+	KeyF6
+	KeyF7
+	KeyF8
+	KeyF9
+	KeyF10
+	KeyF11
+	KeyF12
+	keyCount
+)
+
+type MouseButton int
+
+const (
+	MouseButtonLeft MouseButton = iota
+	MouseButtonRight
+	MouseButtonMiddle
+)
@@ -0,0 +1,163 @@
+package graphos
+
+// BlendMode selects how a drawn colour is combined with the pixel already
+// on the surface. Colours are straight (not premultiplied) RGBA; the
+// surface stores premultiplied pixels like image.RGBA.
+type BlendMode int
+
+const (
+	// BlendOver is source-over alpha compositing, the default.
+	BlendOver BlendMode = iota
+	// BlendReplace writes the colour, alpha included, ignoring the pixel
+	// underneath.
+	BlendReplace
+	// BlendAdd adds the colour, weighted by its alpha, saturating at white.
+	BlendAdd
+	// BlendMultiply multiplies the pixel by the colour, weighted by its alpha.
+	BlendMultiply
+	// BlendXor XORs the RGB channels with the colour. Drawing the same
+	// shape twice restores the original pixels.
+	BlendXor
+	// BlendOr ORs the RGB channels with the colour.
+	BlendOr
+	// BlendAnd ANDs the RGB channels with the colour.
+	BlendAnd
+	// BlendNot writes the inverted colour to the RGB channels.
+	BlendNot
+)
+
+// BGI write modes. Like BlendXor, the bitwise modes leave alpha alone.
+const (
+	CopyPut = BlendReplace
+	XorPut  = BlendXor
+	OrPut   = BlendOr
+	AndPut  = BlendAnd
+	NotPut  = BlendNot
+)
+
+// WithBlend runs draw with the blend mode temporarily set to mode.
+func (s *Surface) WithBlend(mode BlendMode, draw func()) {
+	old := s.Blend
+	s.Blend = mode
+	draw()
+	s.Blend = old
+}
+
+// opaque reports whether drawing c under the current blend mode is a plain
+// copy of its bytes, which the primitives use as a fast path.
+func (s *Surface) opaque(c Color) bool {
+	return c[3] == 0xFF && (s.Blend == BlendOver || s.Blend == BlendReplace)
+}
+
+func premultiply(c Color) Color {
+	if c[3] == 0xFF {
+		return c
+	}
+	a := uint32(c[3])
+	return Color{
+		uint8((uint32(c[0])*a + 127) / 255),
+		uint8((uint32(c[1])*a + 127) / 255),
+		uint8((uint32(c[2])*a + 127) / 255),
+		c[3],
+	}
+}
+
+// idempotent reports whether drawing a pixel twice with the current blend
+// mode, colour and fill style gives the same result as drawing it once.
+func (s *Surface) idempotent() bool {
+	switch s.Blend {
+	case BlendReplace, BlendOr, BlendAnd, BlendNot:
+		return true
+	case BlendOver:
+		return s.CurrentColor[3] == 0xFF && s.FillStyle == nil
+	}
+	return false
+}
+
+// once makes every pixel drawn until the returned function is called blend
+// at most once, so the overlapping pieces of a primitive (box corners,
+// circle octants, thick line joins) do not XOR or blend twice. Calls nest;
+// the outermost one decides.
+func (s *Surface) once() func() {
+	if s.onceDepth == 0 && s.idempotent() {
+		return func() {}
+	}
+	if s.onceDepth == 0 {
+		if n := len(s.img.Pix) / 4; len(s.stamp) != n {
+			s.stamp = make([]uint32, n)
+			s.gen = 0
+		}
+		s.gen++
+		if s.gen == 0 {
+			clear(s.stamp)
+			s.gen = 1
+		}
+	}
+	s.onceDepth++
+	return func() { s.onceDepth-- }
+}
+
+// blend combines c into the pixel at offset pos using the current blend
+// mode.
+func (s *Surface) blend(pos int, c Color) {
+	if s.onceDepth > 0 {
+		if s.stamp[pos/4] == s.gen {
+			return
+		}
+		s.stamp[pos/4] = s.gen
+	}
+	dst := s.img.Pix[pos : pos+4]
+	a := uint32(c[3])
+	switch s.Blend {
+	case BlendReplace:
+		c = premultiply(c)
+		copy(dst[:4], c[:])
+	case BlendAdd:
+		for j := 0; j < 3; j++ {
+			v := uint32(dst[j]) + (uint32(c[j])*a+127)/255
+			if v > 0xFF {
+				v = 0xFF
+			}
+			dst[j] = uint8(v)
+		}
+		v := uint32(dst[3]) + a
+		if v > 0xFF {
+			v = 0xFF
+		}
+		dst[3] = uint8(v)
+	case BlendMultiply:
+		for j := 0; j < 3; j++ {
+			f := (uint32(c[j])*a + (255-a)*255 + 127) / 255
+			dst[j] = uint8((uint32(dst[j])*f + 127) / 255)
+		}
+	case BlendXor:
+		dst[0] ^= c[0]
+		dst[1] ^= c[1]
+		dst[2] ^= c[2]
+	case BlendOr:
+		dst[0] |= c[0]
+		dst[1] |= c[1]
+		dst[2] |= c[2]
+	case BlendAnd:
+		dst[0] &= c[0]
+		dst[1] &= c[1]
+		dst[2] &= c[2]
+	case BlendNot:
+		dst[0] = ^c[0]
+		dst[1] = ^c[1]
+		dst[2] = ^c[2]
+	default:
+		if a == 0xFF {
+			copy(dst[:4], c[:])
+			return
+		}
+		if a == 0 {
+			return
+		}
+		ia := 255 - a
+		dst[0] = uint8((uint32(c[0])*a + uint32(dst[0])*ia + 127) / 255)
+		dst[1] = uint8((uint32(c[1])*a + uint32(dst[1])*ia + 127) / 255)
+		dst[2] = uint8((uint32(c[2])*a + uint32(dst[2])*ia + 127) / 255)
+		dst[3] = uint8((a*255 + uint32(dst[3])*ia + 127) / 255)
+	}
+}
@@ -0,0 +1,80 @@
+package graphos
+
+import (
+	"bytes"
+	"image"
+	"testing"
+)
+
+func TestXorTwiceRestores(t *testing.T) {
+	draw := []func(s *Surface){
+		func(s *Surface) { s.DrawLine(3, 90, 97, 4) },
+		func(s *Surface) { s.DrawBox(5, 5, 90, 70) },
+		func(s *Surface) { s.DrawCircle(50, 50, 20) },
+		func(s *Surface) { s.DrawFilledCircle(30, 30, 9) },
+		func(s *Surface) { s.DrawEllipseArc(60, 50, 30, 20, 0, 360) },
+		func(s *Surface) { s.DrawRoundedBox(10, 10, 90, 90, 15) },
+		func(s *Surface) { s.DrawPolygon([]image.Point{{1, 1}, {80, 20}, {40, 90}}) },
+		func(s *Surface) { s.DrawFilledPolygon([]image.Point{{1, 1}, {80, 20}, {40, 90}}, FillNonZero) },
+		func(s *Surface) { s.DrawString("Hi!", 15, 0, 20, 60) },
+		func(s *Surface) {
+			s.LineWidth, s.LineJoin, s.LineCap = 5, JoinRound, CapRound
+			s.DrawPolyline([]image.Point{{1, 1}, {80, 20}, {40, 90}, {99, 95}})
+		},
+		func(s *Surface) {
+			s.LinePattern = 0xF0F0
+			s.DrawPolyline([]image.Point{{1, 1}, {80, 20}, {40, 90}})
+		},
+		func(s *Surface) {
+			p := NewPath()
+			p.MoveTo(0, 0)
+			p.CubicTo(100, 0, 0, 100, 100, 100)
+			p.Close()
+			s.StrokePath(p)
+		},
+	}
+	for n, f := range draw {
+		s := NewSurface(100, 100)
+		s.CurrentColor = Color{10, 200, 30, 255}
+		s.DrawFilledCircle(50, 50, 30)
+		orig := bytes.Clone(s.img.Pix)
+
+		s.Blend = XorPut
+		s.CurrentColor = white
+		f(s)
+		if bytes.Equal(orig, s.img.Pix) {
+			t.Fatalf("%v: nothing drawn", n)
+		}
+		f(s)
+		if !bytes.Equal(orig, s.img.Pix) {
+			t.Fatalf("%v: drawing twice under XOR did not restore the surface", n)
+		}
+	}
+}
+
+// Under XOR every pixel of a shape must be written once, so it gets the
+// same pixels as with the default mode.
+func TestXorSameShape(t *testing.T) {
+	for n, f := range []func(s *Surface){
+		func(s *Surface) { s.DrawCircle(50, 50, 20) },
+		func(s *Surface) { s.DrawFilledCircle(50, 50, 20) },
+		func(s *Surface) { s.DrawBox(5, 5, 90, 70) },
+		func(s *Surface) { s.DrawEllipse(50, 50, 30, 0) },
+		func(s *Surface) { s.DrawRoundedBox(10, 10, 90, 90, 20) },
+		func(s *Surface) {
+			s.LineWidth, s.LineJoin = 6, JoinRound
+			s.DrawPolygon([]image.Point{{10, 10}, {80, 20}, {40, 90}})
+		},
+	} {
+		a, b := NewSurface(100, 100), NewSurface(100, 100)
+		a.CurrentColor, b.CurrentColor = white, white
+		b.Blend = XorPut
+		f(a)
+		f(b)
+		for j := 0; j < len(a.img.Pix); j += 4 {
+			if a.img.Pix[j] != b.img.Pix[j] {
+				t.Fatalf("%v: pixel %v,%v differs", n, j/4%100, j/400)
+			}
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package graphos
+
+import "image"
+
+// SetClip restricts drawing to r. Pixels outside the clip rectangle are
+// never written by any primitive. r is intersected with the surface bounds.
+func (s *Surface) SetClip(r image.Rectangle) {
+	s.clip = r.Canon().Intersect(s.img.Rect)
+}
+
+// ResetClip makes the whole surface drawable again.
+func (s *Surface) ResetClip() {
+	s.clip = s.img.Rect
+}
+
+func (s *Surface) Clip() image.Rectangle {
+	return s.clip
+}
+
+func (s *Surface) inClip(x, y int) bool {
+	c := s.clip
+	return x >= c.Min.X && x < c.Max.X && y >= c.Min.Y && y < c.Max.Y
+}
+
+// lineSteps returns the first and last step of the Bresenham line from
+// x0, y0 to x1, y1 (see bresenhamSteps) whose pixels are inside the clip
+// rectangle. It reports false when none is.
+func (s *Surface) lineSteps(x0, y0, x1, y1 int) (int, int, bool) {
+	c := s.clip
+	if c.Empty() {
+		return 0, 0, false
+	}
+	dx, dy := abs(x1-x0), abs(y1-y0)
+
+	// p is the major axis and q the minor one
+	p0, dp, pmin, pmax := x0, x1-x0, c.Min.X, c.Max.X-1
+	q0, dq, qmin, qmax := y0, y1-y0, c.Min.Y, c.Max.Y-1
+	a, b := dy, dx
+	if dy > dx {
+		p0, dp, pmin, pmax, q0, dq, qmin, qmax = q0, dq, qmin, qmax, p0, dp, pmin, pmax
+		a, b = dx, dy
+	}
+
+	lo, hi := axisRange(p0, dp, pmin, pmax)
+	first, last := max(0, lo), min(b, hi)
+
+	// the minor offset floor((2ak + b - 1) / 2b) of step k must be in lo..hi
+	lo, hi = axisRange(q0, dq, qmin, qmax)
+	if a == 0 {
+		if lo > 0 || hi < 0 {
+			return 0, 0, false
+		}
+	} else {
+		first = max(first, -floorDiv(b-1-2*b*lo, 2*a))
+		last = min(last, floorDiv(2*b*hi+b, 2*a))
+	}
+	return first, last, first <= last
+}
+
+// axisRange returns the range of offsets t for which p0 + t, moving in the
+// direction of d, is within lo..hi.
+func axisRange(p0, d, lo, hi int) (int, int) {
+	if d < 0 {
+		return p0 - hi, p0 - lo
+	}
+	return lo - p0, hi - p0
+}
+
+// hline draws the horizontal span x0..x1 (inclusive) clipped to the clip
+// rectangle.
+func (s *Surface) hline(x0, x1, y int, color Color) {
+	if x0 > x1 {
+		x0, x1 = x1, x0
+	}
+	c := s.clip
+	if y < c.Min.Y || y >= c.Max.Y {
+		return
+	}
+	if x0 < c.Min.X {
+		x0 = c.Min.X
+	}
+	if x1 >= c.Max.X {
+		x1 = c.Max.X - 1
+	}
+	if x0 > x1 {
+		return
+	}
+	pos := s.img.PixOffset(x0, y)
+	if s.opaque(color) {
+		for x := x0; x <= x1; x++ {
+			copy(s.img.Pix[pos:pos+4], color[:])
+			pos += 4
+		}
+	} else {
+		for x := x0; x <= x1; x++ {
+			s.blend(pos, color)
+			pos += 4
+		}
+	}
+	s.damage(image.Rect(x0, y, x1+1, y+1))
+}
+
+// vline draws the vertical span y0..y1 (inclusive) clipped to the clip
+// rectangle.
+func (s *Surface) vline(x, y0, y1 int, color Color) {
+	if y0 > y1 {
+		y0, y1 = y1, y0
+	}
+	c := s.clip
+	if x < c.Min.X || x >= c.Max.X {
+		return
+	}
+	if y0 < c.Min.Y {
+		y0 = c.Min.Y
+	}
+	if y1 >= c.Max.Y {
+		y1 = c.Max.Y - 1
+	}
+	if y0 > y1 {
+		return
+	}
+	pos := s.img.PixOffset(x, y0)
+	for y := y0; y <= y1; y++ {
+		s.blend(pos, color)
+		pos += s.img.Stride
+	}
+	s.damage(image.Rect(x, y0, x+1, y1+1))
+}
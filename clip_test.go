@@ -0,0 +1,110 @@
+package graphos
+
+import (
+	"image"
+	"math/rand"
+	"testing"
+)
+
+var white = Color{255, 255, 255, 255}
+
+// lit returns the pixels of s with a non-zero alpha.
+func lit(s *Surface) map[image.Point]bool {
+	m := map[image.Point]bool{}
+	b := s.img.Rect
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if s.img.Pix[s.img.PixOffset(x, y)+3] != 0 {
+				m[image.Point{x, y}] = true
+			}
+		}
+	}
+	return m
+}
+
+// sameInClip reports whether the clipped surface c has exactly the pixels
+// inside clip of the unclipped surface u, which is drawn shifted by off.
+func sameInClip(u, c *Surface, off image.Point, clip image.Rectangle) bool {
+	a, b := lit(u), lit(c)
+	n := 0
+	for p := range a {
+		p = p.Sub(off)
+		if !p.In(clip) {
+			continue
+		}
+		if !b[p] {
+			return false
+		}
+		n++
+	}
+	return n == len(b)
+}
+
+func TestDrawLineClipped(t *testing.T) {
+	s := NewSurface(40, 40)
+	s.CurrentColor = white
+	s.DrawLine(-7, 3, 30, 20)
+	for _, p := range []image.Point{{1, 7}, {3, 8}, {5, 9}} {
+		if !lit(s)[p] {
+			t.Errorf("pixel %v of the clipped line is not set", p)
+		}
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for n := 0; n < 2000; n++ {
+		x0, y0 := rng.Intn(120)-40, rng.Intn(120)-40
+		x1, y1 := rng.Intn(120)-40, rng.Intn(120)-40
+		clip := image.Rect(rng.Intn(40), rng.Intn(40), rng.Intn(40), rng.Intn(40)).Canon()
+		for _, pattern := range []uint16{0, 0xF0C3} {
+			// u is big enough for the whole line
+			u, c := NewSurface(120, 120), NewSurface(40, 40)
+			for _, s := range []*Surface{u, c} {
+				s.CurrentColor = white
+				s.LinePattern = pattern
+			}
+			c.SetClip(clip)
+			u.DrawLine(x0+40, y0+40, x1+40, y1+40)
+			c.DrawLine(x0, y0, x1, y1)
+			if !sameInClip(u, c, image.Pt(40, 40), clip) {
+				t.Fatalf("line %v,%v-%v,%v pattern %#x clipped to %v differs", x0, y0, x1, y1, pattern, clip)
+			}
+		}
+	}
+}
+
+func TestDrawCircleClipped(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for n := 0; n < 500; n++ {
+		x0, y0, r := rng.Intn(120)-40, rng.Intn(120)-40, rng.Intn(60)
+		clip := image.Rect(rng.Intn(40), rng.Intn(40), rng.Intn(40), rng.Intn(40)).Canon()
+		for _, filled := range []bool{false, true} {
+			// u is big enough for the whole circle
+			u, c := NewSurface(240, 240), NewSurface(40, 40)
+			u.CurrentColor, c.CurrentColor = white, white
+			c.SetClip(clip)
+			if filled {
+				u.DrawFilledCircle(x0+100, y0+100, r)
+				c.DrawFilledCircle(x0, y0, r)
+			} else {
+				u.DrawCircle(x0+100, y0+100, r)
+				c.DrawCircle(x0, y0, r)
+			}
+			if !sameInClip(u, c, image.Pt(100, 100), clip) {
+				t.Fatalf("circle %v,%v radius %v filled %v clipped to %v differs", x0, y0, r, filled, clip)
+			}
+		}
+	}
+}
+
+func TestHugeCircle(t *testing.T) {
+	s := NewSurface(40, 40)
+	s.CurrentColor = white
+	// the circles cross the screen; walking them whole would take seconds
+	s.DrawCircle(20, 100_000_010, 100_000_000)
+	s.DrawFilledCircle(20, -99_999_970, 100_000_000)
+	for _, p := range []image.Point{{20, 10}, {20, 30}, {20, 0}, {0, 29}} {
+		if !lit(s)[p] {
+			t.Errorf("pixel %v is not set", p)
+		}
+	}
+}
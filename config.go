@@ -0,0 +1,84 @@
+package graphos
+
+import (
+	"image"
+	"time"
+)
+
+type ResizingMode int
+
+const (
+	ResizingEnabled ResizingMode = iota
+	ResizingDisabled
+	ResizingOnlyFullscreen
+)
+
+type CursorMode int
+
+const (
+	CursorVisible CursorMode = iota
+	CursorHidden
+	CursorCaptured
+)
+
+type ScaleMode int
+
+const (
+	// ScaleFit scales the framebuffer as much as the window allows while
+	// keeping its aspect ratio, filling the rest with bars.
+	ScaleFit ScaleMode = iota
+	// ScaleInteger scales by the largest whole factor that fits, so every
+	// framebuffer pixel covers the same number of screen pixels.
+	ScaleInteger
+	// ScaleStretch fills the window, distorting the aspect ratio.
+	ScaleStretch
+	// ScaleResize reallocates the framebuffer to the size of the window.
+	// ScreenHandler sees the new Width and Height on the next tick.
+	ScaleResize
+)
+
+// Config holds the settings read by Run and the game loop. The zero value
+// is usable and matches DefaultConfig.
+type Config struct {
+	// TPS is the number of ticks per second. Zero or less means 24.
+	TPS int
+	// FixedTimestep makes ScreenHandler run at exactly TPS steps per second
+	// of wall-clock time, catching up or waiting as needed, with Delta
+	// always equal to one step. RenderHandler, if set, is then called once
+	// per frame with Alpha holding the fraction of a step not yet simulated.
+	FixedTimestep bool
+
+	Fullscreen bool
+	// Scale multiplies the initial window size. Zero or less means 1.
+	Scale        float64
+	Resizing     ResizingMode
+	ScaleMode    ScaleMode
+	DisableVSync bool
+	Cursor       CursorMode
+	Undecorated  bool
+	Floating     bool
+	// Position is the initial top-left corner of the window. Nil lets the
+	// system place it.
+	Position *image.Point
+	// Icon holds the window icon in one or more sizes.
+	Icon []image.Image
+}
+
+func DefaultConfig() Config {
+	return Config{
+		TPS:   24,
+		Scale: 1,
+	}
+}
+
+func (c Config) tps() int {
+	if c.TPS <= 0 {
+		return 24
+	}
+	return c.TPS
+}
+
+// TickDuration returns the length of one tick at the configured TPS.
+func (i *Instance) TickDuration() time.Duration {
+	return time.Second / time.Duration(i.Config.tps())
+}
@@ -0,0 +1,355 @@
+//go:build !headless
+
+package graphos
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/audio"
+	"github.com/hajimehoshi/ebiten/v2/audio/wav"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+const (
+	sampleRate = 44100
+)
+
+// newBackend returns the ebiten backend. Building with the headless tag
+// replaces it with HeadlessBackend, for machines without a display or the
+// X11 and ALSA headers.
+func newBackend() backend {
+	return &ebitenBackend{}
+}
+
+var ebitenKeys = [keyCount]ebiten.Key{
+	KeyA:            ebiten.KeyA,
+	KeyB:            ebiten.KeyB,
+	KeyC:            ebiten.KeyC,
+	KeyD:            ebiten.KeyD,
+	KeyE:            ebiten.KeyE,
+	KeyF:            ebiten.KeyF,
+	KeyG:            ebiten.KeyG,
+	KeyH:            ebiten.KeyH,
+	KeyI:            ebiten.KeyI,
+	KeyJ:            ebiten.KeyJ,
+	KeyK:            ebiten.KeyK,
+	KeyL:            ebiten.KeyL,
+	KeyM:            ebiten.KeyM,
+	KeyN:            ebiten.KeyN,
+	KeyO:            ebiten.KeyO,
+	KeyP:            ebiten.KeyP,
+	KeyQ:            ebiten.KeyQ,
+	KeyR:            ebiten.KeyR,
+	KeyS:            ebiten.KeyS,
+	KeyT:            ebiten.KeyT,
+	KeyU:            ebiten.KeyU,
+	KeyV:            ebiten.KeyV,
+	KeyW:            ebiten.KeyW,
+	KeyX:            ebiten.KeyX,
+	KeyY:            ebiten.KeyY,
+	KeyZ:            ebiten.KeyZ,
+	Key0:            ebiten.Key0,
+	Key1:            ebiten.Key1,
+	Key2:            ebiten.Key2,
+	Key3:            ebiten.Key3,
+	Key4:            ebiten.Key4,
+	Key5:            ebiten.Key5,
+	Key6:            ebiten.Key6,
+	Key7:            ebiten.Key7,
+	Key8:            ebiten.Key8,
+	Key9:            ebiten.Key9,
+	KeySpace:        ebiten.KeySpace,
+	KeyComma:        ebiten.KeyComma,
+	KeyPeriod:       ebiten.KeyPeriod,
+	KeyMinus:        ebiten.KeyMinus,
+	KeyEqual:        ebiten.KeyEqual,
+	KeyLeftBracket:  ebiten.KeyBracketLeft,
+	KeyRightBracket: ebiten.KeyBracketRight,
+	KeyBackslash:    ebiten.KeyBackslash,
+	KeySemicolon:    ebiten.KeySemicolon,
+	KeyApostrophe:   ebiten.KeyQuote,
+	KeySlash:        ebiten.KeySlash,
+	KeyGraveAccent:  ebiten.KeyBackquote,
+	KeyEnter:        ebiten.KeyEnter,
+	KeyBackspace:    ebiten.KeyBackspace,
+	KeyTab:          ebiten.KeyTab,
+	KeyEscape:       ebiten.KeyEscape,
+	KeyShift:        ebiten.KeyShift,
+	KeyControl:      ebiten.KeyControl,
+	KeyAlt:          ebiten.KeyAlt,
+	KeyUp:           ebiten.KeyArrowUp,
+	KeyDown:         ebiten.KeyArrowDown,
+	KeyLeft:         ebiten.KeyArrowLeft,
+	KeyRight:        ebiten.KeyArrowRight,
+	KeyHome:         ebiten.KeyHome,
+	KeyEnd:          ebiten.KeyEnd,
+	KeyPageUp:       ebiten.KeyPageUp,
+	KeyPageDown:     ebiten.KeyPageDown,
+	KeyInsert:       ebiten.KeyInsert,
+	KeyDelete:       ebiten.KeyDelete,
+	KeyF1:           ebiten.KeyF1,
+	KeyF2:           ebiten.KeyF2,
+	KeyF3:           ebiten.KeyF3,
+	KeyF4:           ebiten.KeyF4,
+	KeyF5:           ebiten.KeyF5,
+	KeyF6:           ebiten.KeyF6,
+	KeyF7:           ebiten.KeyF7,
+	KeyF8:           ebiten.KeyF8,
+	KeyF9:           ebiten.KeyF9,
+	KeyF10:          ebiten.KeyF10,
+	KeyF11:          ebiten.KeyF11,
+	KeyF12:          ebiten.KeyF12,
+}
+
+var ebitenMouseButtons = [...]ebiten.MouseButton{
+	MouseButtonLeft:   ebiten.MouseButtonLeft,
+	MouseButtonRight:  ebiten.MouseButtonRight,
+	MouseButtonMiddle: ebiten.MouseButtonMiddle,
+}
+
+var ebitenResizingModes = [...]ebiten.WindowResizingModeType{
+	ResizingEnabled:        ebiten.WindowResizingModeEnabled,
+	ResizingDisabled:       ebiten.WindowResizingModeDisabled,
+	ResizingOnlyFullscreen: ebiten.WindowResizingModeOnlyFullscreenEnabled,
+}
+
+var ebitenCursorModes = [...]ebiten.CursorModeType{
+	CursorVisible:  ebiten.CursorModeVisible,
+	CursorHidden:   ebiten.CursorModeHidden,
+	CursorCaptured: ebiten.CursorModeCaptured,
+}
+
+// ebitenBackend is the default backend. It implements VideoBackend,
+// InputBackend and AudioBackend on top of ebiten.
+type ebitenBackend struct {
+	// transform of the framebuffer on screen, used to map the cursor back
+	sx, sy, tx, ty float64
+
+	audioContext *audio.Context
+	audioPlayer  *audio.Player
+	isPlaying    bool
+}
+
+type ebitenGame struct {
+	i     *Instance
+	b     *ebitenBackend
+	frame *ebiten.Image
+	buf   []byte
+
+	resizeWidth, resizeHeight int
+}
+
+func (g *ebitenGame) Update() error {
+	if !g.i.Running {
+		return ebiten.Termination
+	}
+	if g.resizeWidth > 0 && g.resizeHeight > 0 &&
+		(g.resizeWidth != g.i.Width || g.resizeHeight != g.i.Height) {
+		g.i.Lock()
+		g.i.Resize(g.resizeWidth, g.resizeHeight)
+		g.i.Unlock()
+	}
+	return g.i.Update()
+}
+
+func (g *ebitenGame) Draw(screen *ebiten.Image) {
+	g.i.Lock()
+	g.upload()
+	g.i.Unlock()
+
+	sx, sy, tx, ty := g.b.transform(g.i, screen.Bounds().Dx(), screen.Bounds().Dy())
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(sx, sy)
+	op.GeoM.Translate(tx, ty)
+	op.Filter = ebiten.FilterLinear
+	if sx == float64(int(sx)) && sy == float64(int(sy)) {
+		op.Filter = ebiten.FilterNearest
+	}
+	screen.DrawImage(g.frame, op)
+}
+
+// upload copies the damaged part of the framebuffer to the frame texture,
+// recreating it when the framebuffer size changed.
+func (g *ebitenGame) upload() {
+	img := g.i.img
+	r := g.i.Present()
+	if g.frame == nil || g.frame.Bounds() != img.Rect {
+		if g.frame != nil {
+			g.frame.Deallocate()
+		}
+		g.frame = ebiten.NewImage(img.Rect.Dx(), img.Rect.Dy())
+		r = img.Rect
+	}
+	if r.Empty() {
+		return
+	}
+	if r == img.Rect {
+		g.frame.WritePixels(img.Pix)
+		return
+	}
+
+	rowLen := 4 * r.Dx()
+	n := rowLen * r.Dy()
+	if cap(g.buf) < n {
+		g.buf = make([]byte, n)
+	}
+	g.buf = g.buf[:n]
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		pos := img.PixOffset(r.Min.X, y)
+		copy(g.buf[(y-r.Min.Y)*rowLen:], img.Pix[pos:pos+rowLen])
+	}
+	g.frame.SubImage(r).(*ebiten.Image).WritePixels(g.buf)
+}
+
+func (g *ebitenGame) Layout(outsideWidth, outsideHeight int) (int, int) {
+	f := ebiten.Monitor().DeviceScaleFactor()
+	w := int(float64(outsideWidth) * f)
+	h := int(float64(outsideHeight) * f)
+	if g.i.Config.ScaleMode == ScaleResize {
+		g.resizeWidth, g.resizeHeight = w, h
+	}
+	return w, h
+}
+
+// transform returns the scale and offset that place the framebuffer of i
+// on a screen of the given size according to the scale mode.
+func (b *ebitenBackend) transform(i *Instance, screenWidth, screenHeight int) (sx, sy, tx, ty float64) {
+	w, h := float64(i.Width), float64(i.Height)
+	ow, oh := float64(screenWidth), float64(screenHeight)
+
+	switch i.Config.ScaleMode {
+	case ScaleStretch:
+		sx, sy = ow/w, oh/h
+	case ScaleInteger:
+		sx = math.Floor(math.Min(ow/w, oh/h))
+		if sx < 1 {
+			sx = math.Min(ow/w, oh/h)
+		}
+		sy = sx
+	case ScaleResize:
+		sx, sy = 1, 1
+		if w > ow || h > oh {
+			sx = math.Min(ow/w, oh/h)
+			sy = sx
+		}
+	default:
+		sx = math.Min(ow/w, oh/h)
+		sy = sx
+	}
+	tx = math.Floor((ow - w*sx) / 2)
+	ty = math.Floor((oh - h*sy) / 2)
+
+	b.sx, b.sy, b.tx, b.ty = sx, sy, tx, ty
+	return sx, sy, tx, ty
+}
+
+func (b *ebitenBackend) Run(i *Instance) error {
+	cfg := i.Config
+	scale := cfg.Scale
+	if scale <= 0 {
+		scale = 1
+	}
+
+	ebiten.SetWindowTitle(i.Title)
+	ebiten.SetWindowSize(int(float64(i.Width)*scale), int(float64(i.Height)*scale))
+	ebiten.SetWindowResizingMode(ebitenResizingModes[cfg.Resizing])
+	ebiten.SetWindowDecorated(!cfg.Undecorated)
+	ebiten.SetWindowFloating(cfg.Floating)
+	if cfg.Position != nil {
+		ebiten.SetWindowPosition(cfg.Position.X, cfg.Position.Y)
+	}
+	if len(cfg.Icon) > 0 {
+		ebiten.SetWindowIcon(cfg.Icon)
+	}
+	ebiten.SetFullscreen(cfg.Fullscreen)
+	ebiten.SetTPS(cfg.tps())
+	if cfg.FixedTimestep {
+		// the instance keeps its own fixed step; tick once per frame
+		ebiten.SetTPS(ebiten.SyncWithFPS)
+	}
+	ebiten.SetVsyncEnabled(!cfg.DisableVSync)
+	ebiten.SetCursorMode(ebitenCursorModes[cfg.Cursor])
+
+	err := ebiten.RunGame(&ebitenGame{i: i, b: b})
+	if err == ebiten.Termination {
+		return nil
+	}
+	return err
+}
+
+func (b *ebitenBackend) IsKeyPressed(key Key) bool {
+	return ebiten.IsKeyPressed(ebitenKeys[key])
+}
+
+func (b *ebitenBackend) IsKeyJustPressed(key Key) bool {
+	return inpututil.IsKeyJustPressed(ebitenKeys[key])
+}
+
+func (b *ebitenBackend) IsKeyJustReleased(key Key) bool {
+	return inpututil.IsKeyJustReleased(ebitenKeys[key])
+}
+
+func (b *ebitenBackend) IsMouseButtonPressed(button MouseButton) bool {
+	return ebiten.IsMouseButtonPressed(ebitenMouseButtons[button])
+}
+
+func (b *ebitenBackend) IsMouseButtonJustPressed(button MouseButton) bool {
+	return inpututil.IsMouseButtonJustPressed(ebitenMouseButtons[button])
+}
+
+func (b *ebitenBackend) IsMouseButtonJustReleased(button MouseButton) bool {
+	return inpututil.IsMouseButtonJustReleased(ebitenMouseButtons[button])
+}
+
+// CursorPosition returns the cursor position in framebuffer coordinates.
+func (b *ebitenBackend) CursorPosition() (int, int) {
+	x, y := ebiten.CursorPosition()
+	if b.sx == 0 || b.sy == 0 {
+		return x, y
+	}
+	return int(math.Floor((float64(x) - b.tx) / b.sx)),
+		int(math.Floor((float64(y) - b.ty) / b.sy))
+}
+
+func (b *ebitenBackend) AppendInputChars(runes []rune) []rune {
+	return ebiten.AppendInputChars(runes)
+}
+
+func (b *ebitenBackend) LoadWavLoop(r io.Reader) error {
+	if b.audioContext == nil {
+		b.audioContext = audio.NewContext(sampleRate)
+	}
+
+	// Decodifique o arquivo WAV
+	wavStream, err := wav.Decode(b.audioContext, r)
+	if err != nil {
+		return fmt.Errorf("decode wav: %v", err)
+	}
+
+	xs := audio.NewInfiniteLoop(wavStream, wavStream.Length())
+
+	b.audioPlayer, err = b.audioContext.NewPlayer(xs)
+	if err != nil {
+		return fmt.Errorf("new player: %v", err)
+	}
+
+	return nil
+}
+
+func (b *ebitenBackend) Pause() {
+	if b.audioPlayer != nil {
+		b.audioPlayer.Pause()
+		//audioPlayer.Seek(0)
+		b.isPlaying = false
+	}
+}
+
+func (b *ebitenBackend) Play() {
+	if b.isPlaying || b.audioPlayer == nil {
+		return
+	}
+	b.audioPlayer.Play()
+}
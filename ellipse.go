@@ -0,0 +1,336 @@
+package graphos
+
+import (
+	"math"
+	"slices"
+	"sort"
+)
+
+// Angles are in degrees, counterclockwise from the positive x axis (three
+// o'clock), as in BGI. Filled shapes are drawn as their outline plus the
+// interior, so their edge pixels are exactly those of the outline.
+
+// quadrant calls plot for every outline point dx, dy >= 0 of the axis
+// aligned ellipse with radii rx and ry. Circles use the same steps as
+// DrawCircle.
+func quadrant(rx, ry int, plot func(dx, dy int)) {
+	if rx == ry {
+		x, y, e := rx, 0, 0
+		for x >= y {
+			plot(x, y)
+			if x != y {
+				plot(y, x)
+			}
+			if e <= 0 {
+				y += 1
+				e += 2*y + 1
+			}
+			if e > 0 {
+				x -= 1
+				e -= 2*x + 1
+			}
+		}
+		return
+	}
+
+	// midpoint ellipse algorithm
+	rx2, ry2 := float64(rx*rx), float64(ry*ry)
+	x, y := 0, ry
+	px, py := 0.0, 2*rx2*float64(y)
+	p := ry2 - rx2*float64(ry) + rx2/4
+	for px < py {
+		plot(x, y)
+		x++
+		px += 2 * ry2
+		if p < 0 {
+			p += ry2 + px
+			continue
+		}
+		y--
+		py -= 2 * rx2
+		p += ry2 + px - py
+	}
+	fx, fy := float64(x)+0.5, float64(y-1)
+	p = ry2*fx*fx + rx2*fy*fy - rx2*ry2
+	for y >= 0 {
+		plot(x, y)
+		y--
+		py -= 2 * rx2
+		if p > 0 {
+			p += rx2 - py
+			continue
+		}
+		x++
+		px += 2 * ry2
+		p += rx2 - py + px
+	}
+}
+
+// extents returns, for each dy from 0 to ry, the largest dx of the
+// ellipse outline on that row.
+func extents(rx, ry int) []int {
+	ext := make([]int, ry+1)
+	for j := range ext {
+		ext[j] = -1
+	}
+	quadrant(rx, ry, func(dx, dy int) {
+		if dy <= ry && dx > ext[dy] {
+			ext[dy] = dx
+		}
+	})
+	return ext
+}
+
+func (s *Surface) DrawEllipse(x0, y0, rx, ry int) {
+	defer s.once()()
+	if rx < 0 || ry < 0 {
+		return
+	}
+	if rx == 0 || ry == 0 {
+		s.hline(x0-rx, x0+rx, y0, s.CurrentColor)
+		s.vline(x0, y0-ry, y0+ry, s.CurrentColor)
+		return
+	}
+	quadrant(rx, ry, func(dx, dy int) {
+		s.DrawPix(x0+dx, y0+dy, s.CurrentColor)
+		if dx != 0 {
+			s.DrawPix(x0-dx, y0+dy, s.CurrentColor)
+		}
+		if dy != 0 {
+			s.DrawPix(x0+dx, y0-dy, s.CurrentColor)
+			if dx != 0 {
+				s.DrawPix(x0-dx, y0-dy, s.CurrentColor)
+			}
+		}
+	})
+}
+
+func (s *Surface) DrawFilledEllipse(x0, y0, rx, ry int) {
+	if rx <= 0 || ry <= 0 {
+		// a line when a radius is zero, nothing when one is negative
+		s.DrawEllipse(x0, y0, rx, ry)
+		return
+	}
+	for dy, dx := range extents(rx, ry) {
+		s.fillSpan(x0-dx, x0+dx, y0+dy, s.CurrentColor)
+		if dy != 0 {
+			s.fillSpan(x0-dx, x0+dx, y0-dy, s.CurrentColor)
+		}
+	}
+}
+
+// angleIn reports whether angle a lies on the arc from start to end.
+func angleIn(a, start, end float64) bool {
+	span := end - start
+	if span >= 360 || span <= -360 {
+		return true
+	}
+	span = math.Mod(span+360, 360)
+	d := math.Mod(math.Mod(a-start, 360)+360, 360)
+	return d <= span
+}
+
+// angleOf returns the angle of the offset dx, dy from the centre.
+func angleOf(dx, dy int) float64 {
+	return math.Atan2(float64(-dy), float64(dx)) * 180 / math.Pi
+}
+
+// outlinePoint returns the pixel of the ellipse outline whose angle is
+// nearest to angle.
+func outlinePoint(rx, ry int, angle float64) (int, int) {
+	sn, c := math.Sincos(angle * math.Pi / 180)
+	sx, sy := 1, 1
+	if c < 0 {
+		sx = -1
+	}
+	if sn > 0 {
+		sy = -1
+	}
+	bx, by, best := rx, 0, math.Inf(1)
+	quadrant(rx, ry, func(dx, dy int) {
+		d := math.Mod(math.Abs(angleOf(sx*dx, sy*dy)-angle), 360)
+		d = math.Min(d, 360-d)
+		if d < best {
+			bx, by, best = sx*dx, sy*dy, d
+		}
+	})
+	return bx, by
+}
+
+func (s *Surface) DrawArc(x0, y0, radius int, start, end float64) {
+	s.DrawEllipseArc(x0, y0, radius, radius, start, end)
+}
+
+func (s *Surface) DrawEllipseArc(x0, y0, rx, ry int, start, end float64) {
+	defer s.once()()
+	if rx <= 0 || ry <= 0 {
+		return
+	}
+	quadrant(rx, ry, func(dx, dy int) {
+		for _, q := range [4][2]int{{dx, dy}, {-dx, dy}, {dx, -dy}, {-dx, -dy}} {
+			if angleIn(angleOf(q[0], q[1]), start, end) {
+				s.DrawPix(x0+q[0], y0+q[1], s.CurrentColor)
+			}
+		}
+	})
+}
+
+// DrawPieSlice fills the circular sector from start to end.
+func (s *Surface) DrawPieSlice(x0, y0, radius int, start, end float64) {
+	s.DrawSector(x0, y0, radius, radius, start, end)
+}
+
+// DrawSector fills the elliptical sector from start to end. Its edge is the
+// arc drawn by DrawEllipseArc and two radii ending on the outline pixels
+// nearest to start and end.
+func (s *Surface) DrawSector(x0, y0, rx, ry int, start, end float64) {
+	defer s.once()()
+	if rx <= 0 || ry <= 0 {
+		return
+	}
+	ext := extents(rx, ry)
+	for dy := -ry; dy <= ry; dy++ {
+		s.sectorRow(x0, y0, dy, ext[abs(dy)], start, end)
+	}
+	if end-start >= 360 || end-start <= -360 {
+		return
+	}
+	for _, a := range []float64{start, end} {
+		x1, y1 := outlinePoint(rx, ry, a)
+		bresenham(0, 0, x1, y1, func(x, y int) {
+			if abs(x) <= ext[abs(y)] {
+				s.fillPix(x0+x, y0+y, s.CurrentColor)
+			}
+		})
+	}
+}
+
+// sectorRow fills the pixels dx of row dy, with |dx| <= w, whose angle is
+// from start to end. Along a row the angle is monotonic, so it only
+// crosses start and end once; pixels next to a crossing are tested one by
+// one and the runs in between as a whole.
+func (s *Surface) sectorRow(x0, y0, dy, w int, start, end float64) {
+	in := func(dx int) bool {
+		return (dx == 0 && dy == 0) || angleIn(angleOf(dx, dy), start, end)
+	}
+	cuts := []int{-w, w + 1}
+	for _, a := range []float64{start, end} {
+		c := 0
+		if dy != 0 {
+			c = angleCrossing(dy, w, a)
+		}
+		for k := c - 1; k <= c+1; k++ {
+			if k > -w && k <= w {
+				cuts = append(cuts, k)
+			}
+		}
+	}
+	slices.Sort(cuts)
+	cuts = slices.Compact(cuts)
+
+	// run is the span being collected, from x to the pixel before dx
+	run, x := false, -w
+	add := func(dx0, dx1 int, on bool) {
+		if on != run {
+			if run {
+				s.fillSpan(x0+x, x0+dx0-1, y0+dy, s.CurrentColor)
+			}
+			run, x = on, dx0
+		}
+		if dx1 == w && run {
+			s.fillSpan(x0+x, x0+w, y0+dy, s.CurrentColor)
+		}
+	}
+	for j := 0; j+1 < len(cuts); j++ {
+		a, b := cuts[j], cuts[j+1]-1
+		add(a, a, in(a))
+		if a < b {
+			add(a+1, b, in(a+1))
+		}
+	}
+}
+
+// angleCrossing returns the first pixel dx from -w to w on row dy != 0 whose
+// angle has passed a, or w+1 if none has.
+func angleCrossing(dy, w int, a float64) int {
+	a = math.Mod(a, 360)
+	switch {
+	case a > 180:
+		a -= 360
+	case a <= -180:
+		a += 360
+	}
+	// the angle grows with dx below the centre and falls above it
+	passed := func(dx int) bool {
+		if dy > 0 {
+			return angleOf(dx, dy) >= a
+		}
+		return angleOf(dx, dy) <= a
+	}
+	return -w + sort.Search(2*w+1, func(k int) bool { return passed(-w + k) })
+}
+
+func roundedRadius(x1, y1, x2, y2, radius int) int {
+	if m := (x2 - x1) / 2; radius > m {
+		radius = m
+	}
+	if m := (y2 - y1) / 2; radius > m {
+		radius = m
+	}
+	if radius < 0 {
+		radius = 0
+	}
+	return radius
+}
+
+// DrawRoundedBox draws a box whose corners are quarter circles of the given
+// radius.
+func (s *Surface) DrawRoundedBox(x1, y1, x2, y2, radius int) {
+	defer s.once()()
+	if x1 > x2 {
+		x1, x2 = x2, x1
+	}
+	if y1 > y2 {
+		y1, y2 = y2, y1
+	}
+	r := roundedRadius(x1, y1, x2, y2, radius)
+	s.hline(x1+r, x2-r, y1, s.CurrentColor)
+	s.hline(x1+r, x2-r, y2, s.CurrentColor)
+	s.vline(x1, y1+r, y2-r, s.CurrentColor)
+	s.vline(x2, y1+r, y2-r, s.CurrentColor)
+	if r == 0 {
+		return
+	}
+	l, t, rt, b := x1+r, y1+r, x2-r, y2-r
+	quadrant(r, r, func(dx, dy int) {
+		s.DrawPix(rt+dx, b+dy, s.CurrentColor)
+		s.DrawPix(l-dx, b+dy, s.CurrentColor)
+		s.DrawPix(rt+dx, t-dy, s.CurrentColor)
+		s.DrawPix(l-dx, t-dy, s.CurrentColor)
+	})
+}
+
+func (s *Surface) DrawFilledRoundedBox(x1, y1, x2, y2, radius int) {
+	defer s.once()()
+	if x1 > x2 {
+		x1, x2 = x2, x1
+	}
+	if y1 > y2 {
+		y1, y2 = y2, y1
+	}
+	r := roundedRadius(x1, y1, x2, y2, radius)
+	l, t, rt, b := x1+r, y1+r, x2-r, y2-r
+	for y := t; y <= b; y++ {
+		s.fillSpan(x1, x2, y, s.CurrentColor)
+	}
+	if r == 0 {
+		return
+	}
+	for dy, dx := range extents(r, r) {
+		if dy == 0 {
+			continue
+		}
+		s.fillSpan(l-dx, rt+dx, t-dy, s.CurrentColor)
+		s.fillSpan(l-dx, rt+dx, b+dy, s.CurrentColor)
+	}
+}
@@ -0,0 +1,82 @@
+package graphos
+
+import "testing"
+
+func TestEllipseRadii(t *testing.T) {
+	tests := []struct {
+		rx, ry, want int
+	}{
+		{-5, -5, 0},
+		{-5, 3, 0},
+		{4, -1, 0},
+		{0, 0, 1},
+		{3, 0, 7},
+		{0, 2, 5},
+	}
+	for _, tt := range tests {
+		for _, filled := range []bool{false, true} {
+			s := NewSurface(40, 40)
+			s.CurrentColor = white
+			if filled {
+				s.DrawFilledEllipse(20, 20, tt.rx, tt.ry)
+			} else {
+				s.DrawEllipse(20, 20, tt.rx, tt.ry)
+			}
+			if got := len(lit(s)); got != tt.want {
+				t.Errorf("radii %v, %v filled %v: %v pixels, want %v", tt.rx, tt.ry, filled, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestSectorEdges(t *testing.T) {
+	angles := []float64{0, 30, 37, 90, 135, 180, 200, 270, 315, -45}
+	for rx := 1; rx <= 24; rx += 5 {
+		for ry := 1; ry <= 20; ry += 4 {
+			for _, start := range angles {
+				for _, span := range []float64{10, 45, 90, 170, 180, 250, 359, 360} {
+					end := start + span
+					sector, ellipse, arc := NewSurface(60, 60), NewSurface(60, 60), NewSurface(60, 60)
+					for _, s := range []*Surface{sector, ellipse, arc} {
+						s.CurrentColor = white
+					}
+					sector.DrawSector(30, 30, rx, ry, start, end)
+					ellipse.DrawFilledEllipse(30, 30, rx, ry)
+					arc.DrawEllipseArc(30, 30, rx, ry, start, end)
+					in, full := lit(sector), lit(ellipse)
+					for p := range in {
+						if !full[p] {
+							t.Fatalf("sector %v,%v %v-%v: pixel %v is outside the filled ellipse", rx, ry, start, end, p)
+						}
+					}
+					for p := range lit(arc) {
+						if !in[p] {
+							t.Fatalf("sector %v,%v %v-%v: arc pixel %v is not filled", rx, ry, start, end, p)
+						}
+					}
+					// every pixel whose centre is within the angles is filled
+					for p := range full {
+						dx, dy := p.X-30, p.Y-30
+						if (dx != 0 || dy != 0) && angleIn(angleOf(dx, dy), start, end) && !in[p] {
+							t.Fatalf("sector %v,%v %v-%v: pixel %v is not filled", rx, ry, start, end, p)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// Under XOR the radii and the spans must not cancel each other out.
+func TestSectorXor(t *testing.T) {
+	a, b := NewSurface(60, 60), NewSurface(60, 60)
+	a.CurrentColor, b.CurrentColor = white, white
+	b.Blend = XorPut
+	a.DrawSector(30, 30, 20, 12, 30, 200)
+	b.DrawSector(30, 30, 20, 12, 30, 200)
+	for j := 0; j < len(a.img.Pix); j += 4 {
+		if a.img.Pix[j] != b.img.Pix[j] {
+			t.Fatalf("pixel %v,%v differs", j/4%60, j/240)
+		}
+	}
+}
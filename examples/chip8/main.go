@@ -8,8 +8,6 @@ import (
 	"time"
 
 	"crg.eti.br/go/graphos"
-	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/inpututil"
 )
 
 var (
@@ -34,7 +32,7 @@ func drawRegisters(g *graphos.Instance) {
 }
 
 type keyMap struct {
-	ekey   ebiten.Key
+	gkey   graphos.Key
 	c8key  uint8
 	cx, cy int
 	x, y   int
@@ -58,11 +56,11 @@ func makeKeyMap() []keyMap {
 		0xA, 0x0, 0xB, 0xF,
 	}
 
-	ekeys := []ebiten.Key{
-		ebiten.Key1, ebiten.Key2, ebiten.Key3, ebiten.KeyC,
-		ebiten.Key4, ebiten.Key5, ebiten.Key6, ebiten.KeyD,
-		ebiten.Key7, ebiten.Key8, ebiten.Key9, ebiten.KeyE,
-		ebiten.KeyA, ebiten.Key0, ebiten.KeyB, ebiten.KeyF,
+	gkeys := []graphos.Key{
+		graphos.Key1, graphos.Key2, graphos.Key3, graphos.KeyC,
+		graphos.Key4, graphos.Key5, graphos.Key6, graphos.KeyD,
+		graphos.Key7, graphos.Key8, graphos.Key9, graphos.KeyE,
+		graphos.KeyA, graphos.Key0, graphos.KeyB, graphos.KeyF,
 	}
 
 	xBase, yBase := 500, 300
@@ -75,7 +73,7 @@ func makeKeyMap() []keyMap {
 		}
 
 		km[i] = keyMap{
-			ekey:  ekeys[i],
+			gkey:  gkeys[i],
 			c8key: keys[i],
 			cx:    x,
 			cy:    y,
@@ -135,27 +133,28 @@ func drawDisplay(g *graphos.Instance, x, y int) {
 }
 
 func input(i *graphos.Instance) {
+	in := i.InputDevice
 	for _, v := range key {
-		if inpututil.IsKeyJustPressed(v.ekey) {
+		if in.IsKeyJustPressed(v.gkey) {
 			c8.keys[v.c8key] = true
 			continue
 		}
 
-		if inpututil.IsKeyJustReleased(v.ekey) {
+		if in.IsKeyJustReleased(v.gkey) {
 			c8.keys[v.c8key] = false
 			continue
 		}
 
-		x, y := ebiten.CursorPosition()
+		x, y := in.CursorPosition()
 
-		if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		if in.IsMouseButtonJustPressed(graphos.MouseButtonLeft) {
 			if x >= v.x && x <= v.x1 && y >= v.y && y <= v.y1 {
 				c8.keys[v.c8key] = true
 			}
 			continue
 		}
 
-		if inpututil.IsMouseButtonJustReleased(ebiten.MouseButtonLeft) {
+		if in.IsMouseButtonJustReleased(graphos.MouseButtonLeft) {
 			//if x >= v.x && x <= v.x1 && y >= v.y && y <= v.y1 {
 			c8.keys[v.c8key] = false
 			//}
@@ -278,13 +277,20 @@ func main() {
 
 	go func() {
 		for {
-			if cg.Running {
+			cg.Lock()
+			running := cg.Running
+			if running {
 				c8.Cycle()
-				continue
 			}
-			time.Sleep(1 * time.Millisecond)
+			cg.Unlock()
+			if !running {
+				time.Sleep(1 * time.Millisecond)
+			}
 		}
 	}()
 
-	cg.Run()
+	err = cg.Run()
+	if err != nil {
+		fmt.Println(err)
+	}
 }
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"log"
+
+	"crg.eti.br/go/graphos"
+)
+
+var (
+	plant = graphos.LSystem{
+		Axiom: "X",
+		Rules: []graphos.Rule{
+			{Pred: "X", Succ: "F[+X][-X]FX"},
+			{Pred: "X", Succ: "F[+X]F[-X]+X", Weight: 2},
+			{Pred: "F", Succ: "FF"},
+		},
+		Iterations: 6,
+		Angle:      25,
+		Step:       2,
+	}
+
+	tree = graphos.LSystem{
+		Axiom: "A(120)",
+		Rules: []graphos.Rule{
+			{Pred: "A(l)", Cond: "l > 4", Succ: "F(l)[+(30)A(l*0.7)][-(20)A(l*0.65)]"},
+		},
+		Iterations: 12,
+	}
+
+	drawn bool
+)
+
+func update(screen *graphos.Instance) error {
+	if drawn {
+		return nil
+	}
+	drawn = true
+
+	screen.CurrentColor = graphos.Colors16[0x0A]
+	err := plant.Draw(screen, 200, float64(screen.Height-1), 90)
+	if err != nil {
+		return err
+	}
+	screen.CurrentColor = graphos.Colors16[0x0E]
+	err = tree.Draw(screen, 550, float64(screen.Height-1), 90)
+	if err != nil {
+		return err
+	}
+
+	screen.UpdateScreen = true
+	return nil
+}
+
+func main() {
+	cg := graphos.New()
+	cg.Width = 800
+	cg.Height = 600
+	cg.ScreenHandler = update
+	cg.Title = "L-system"
+	cg.CurrentColor = graphos.Colors16[0x0]
+
+	err := cg.Run()
+	if err != nil {
+		log.Fatal(err)
+	}
+}
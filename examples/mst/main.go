@@ -1,6 +1,7 @@
 package main
 
 import (
+	"log"
 	"math/rand"
 
 	"crg.eti.br/go/graphos"
@@ -164,5 +165,8 @@ func main() {
 		dotMain = append(dotMain, d)
 	}
 
-	cg.Run()
+	err := cg.Run()
+	if err != nil {
+		log.Fatal(err)
+	}
 }
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"log"
 	"math/rand"
 
 	"crg.eti.br/go/graphos"
@@ -55,5 +56,8 @@ func main() {
 		Y: cg.Height / 2,
 	}
 
-	cg.Run()
+	err := cg.Run()
+	if err != nil {
+		log.Fatal(err)
+	}
 }
@@ -47,6 +47,9 @@ func main() {
 	cg.Title = "Graphos - Terminal"
 	cg.CurrentColor = graphos.Colors16[0x0F]
 
-	cg.Run()
+	err := cg.Run()
+	if err != nil {
+		log.Fatal(err)
+	}
 
 }
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"log"
+
+	"crg.eti.br/go/graphos"
+)
+
+func main() {
+	cg := graphos.New()
+	cg.Width = 800
+	cg.Height = 600
+	cg.Title = "Turtle"
+	cg.CurrentColor = graphos.Colors16[0x0]
+	// the turtle draws by itself on every tick
+	cg.ScreenHandler = func(*graphos.Instance) error { return nil }
+
+	t := graphos.NewTurtle(cg)
+	t.Speed = 8
+	t.Visible = true
+
+	for j := 0; j < 36; j++ {
+		t.SetColor(graphos.Colors16[9+j%7])
+		for k := 0; k < 4; k++ {
+			t.Forward(150)
+			t.Right(90)
+		}
+		t.Right(10)
+	}
+
+	err := cg.Run()
+	if err != nil {
+		log.Fatal(err)
+	}
+}
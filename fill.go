@@ -0,0 +1,184 @@
+package graphos
+
+import "image"
+
+// Paint colours the pixels of filled shapes. ColorAt returns the colour of
+// pixel x, y of a shape filled with base, or false to leave the pixel
+// unchanged.
+type Paint interface {
+	ColorAt(x, y int, base Color) (Color, bool)
+}
+
+// Pattern is an 8x8 bitmap fill in the style of BGI setfillstyle. Set bits
+// are painted with the colour of the shape, clear bits with Background, or
+// left untouched when Background is nil. The pattern is anchored to the
+// surface, so adjacent shapes tile seamlessly.
+type Pattern struct {
+	Bits       [8]byte
+	Background *Color
+}
+
+// BGI fill patterns.
+var (
+	EmptyFill      = [8]byte{}
+	SolidFill      = [8]byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}
+	LineFill       = [8]byte{0xFF, 0xFF, 0x00, 0x00, 0xFF, 0xFF, 0x00, 0x00}
+	LtSlashFill    = [8]byte{0x01, 0x02, 0x04, 0x08, 0x10, 0x20, 0x40, 0x80}
+	SlashFill      = [8]byte{0xE0, 0xC1, 0x83, 0x07, 0x0E, 0x1C, 0x38, 0x70}
+	BkSlashFill    = [8]byte{0xF0, 0x78, 0x3C, 0x1E, 0x0F, 0x87, 0xC3, 0xE1}
+	LtBkSlashFill  = [8]byte{0xA5, 0xD2, 0x69, 0xB4, 0x5A, 0x2D, 0x96, 0x4B}
+	HatchFill      = [8]byte{0xFF, 0x88, 0x88, 0x88, 0xFF, 0x88, 0x88, 0x88}
+	XHatchFill     = [8]byte{0x81, 0x42, 0x24, 0x18, 0x18, 0x24, 0x42, 0x81}
+	InterleaveFill = [8]byte{0xCC, 0x33, 0xCC, 0x33, 0xCC, 0x33, 0xCC, 0x33}
+	WideDotFill    = [8]byte{0x80, 0x00, 0x08, 0x00, 0x80, 0x00, 0x08, 0x00}
+	CloseDotFill   = [8]byte{0x88, 0x00, 0x22, 0x00, 0x88, 0x00, 0x22, 0x00}
+)
+
+func (p Pattern) ColorAt(x, y int, base Color) (Color, bool) {
+	if p.Bits[y&7]&(0x80>>(x&7)) != 0 {
+		return base, true
+	}
+	if p.Background == nil {
+		return Color{}, false
+	}
+	return *p.Background, true
+}
+
+// fillSpan fills the span x0..x1 (inclusive) of a filled shape, using
+// FillStyle when set.
+func (s *Surface) fillSpan(x0, x1, y int, c Color) {
+	if s.FillStyle == nil {
+		s.hline(x0, x1, y, c)
+		return
+	}
+	if x0 > x1 {
+		x0, x1 = x1, x0
+	}
+	if x0 < s.clip.Min.X {
+		x0 = s.clip.Min.X
+	}
+	if x1 >= s.clip.Max.X {
+		x1 = s.clip.Max.X - 1
+	}
+	if y < s.clip.Min.Y || y >= s.clip.Max.Y {
+		return
+	}
+	for x := x0; x <= x1; x++ {
+		if pc, ok := s.FillStyle.ColorAt(x, y, c); ok {
+			s.setPix(x, y, pc)
+		}
+	}
+}
+
+// fillPix sets one pixel of a filled shape, using FillStyle when set.
+func (s *Surface) fillPix(x, y int, c Color) {
+	if s.FillStyle == nil {
+		s.DrawPix(x, y, c)
+		return
+	}
+	if pc, ok := s.FillStyle.ColorAt(x, y, c); ok {
+		s.DrawPix(x, y, pc)
+	}
+}
+
+// FloodFill fills the 4-connected area of pixels that have the same colour
+// as x, y.
+func (s *Surface) FloodFill(x, y int, c Color) {
+	s.seedFill(x, y, false, s.sameAs(x, y), c)
+}
+
+// FloodFill8 is like FloodFill but also crosses diagonal corners.
+func (s *Surface) FloodFill8(x, y int, c Color) {
+	s.seedFill(x, y, true, s.sameAs(x, y), c)
+}
+
+// BoundaryFill fills the 4-connected area around x, y up to pixels of the
+// border colour.
+func (s *Surface) BoundaryFill(x, y int, c, border Color) {
+	s.seedFill(x, y, false, s.notColor(border), c)
+}
+
+// BoundaryFill8 is like BoundaryFill but also crosses diagonal corners.
+func (s *Surface) BoundaryFill8(x, y int, c, border Color) {
+	s.seedFill(x, y, true, s.notColor(border), c)
+}
+
+func (s *Surface) sameAs(x, y int) func(p []byte) bool {
+	if !s.inClip(x, y) {
+		return func([]byte) bool { return false }
+	}
+	var seed [4]byte
+	copy(seed[:], s.img.Pix[s.img.PixOffset(x, y):])
+	return func(p []byte) bool {
+		return p[0] == seed[0] && p[1] == seed[1] && p[2] == seed[2] && p[3] == seed[3]
+	}
+}
+
+func (s *Surface) notColor(c Color) func(p []byte) bool {
+	c = premultiply(c)
+	return func(p []byte) bool {
+		return p[0] != c[0] || p[1] != c[1] || p[2] != c[2] || p[3] != c[3]
+	}
+}
+
+// seedFill is a scanline fill with an explicit stack. Pixels are tested
+// against their colour before the fill started, tracked in a visited map,
+// so patterns and blending cannot make it loop.
+func (s *Surface) seedFill(x, y int, diagonal bool, inside func(p []byte) bool, c Color) {
+	r := s.clip
+	if !s.inClip(x, y) {
+		return
+	}
+	w := r.Dx()
+	visited := make([]bool, w*r.Dy())
+	test := func(x, y int) bool {
+		idx := (y-r.Min.Y)*w + x - r.Min.X
+		if visited[idx] {
+			return false
+		}
+		return inside(s.img.Pix[s.img.PixOffset(x, y):])
+	}
+
+	stack := []image.Point{{x, y}}
+	for len(stack) > 0 {
+		p := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if !test(p.X, p.Y) {
+			continue
+		}
+
+		x0, x1 := p.X, p.X
+		for x0 > r.Min.X && test(x0-1, p.Y) {
+			x0--
+		}
+		for x1 < r.Max.X-1 && test(x1+1, p.Y) {
+			x1++
+		}
+		row := (p.Y - r.Min.Y) * w
+		for x := x0; x <= x1; x++ {
+			visited[row+x-r.Min.X] = true
+		}
+		s.fillSpan(x0, x1, p.Y, c)
+
+		lo, hi := x0, x1
+		if diagonal {
+			lo, hi = max(lo-1, r.Min.X), min(hi+1, r.Max.X-1)
+		}
+		for _, ny := range [2]int{p.Y - 1, p.Y + 1} {
+			if ny < r.Min.Y || ny >= r.Max.Y {
+				continue
+			}
+			run := false
+			for x := lo; x <= hi; x++ {
+				if !test(x, ny) {
+					run = false
+					continue
+				}
+				if !run {
+					stack = append(stack, image.Point{x, ny})
+					run = true
+				}
+			}
+		}
+	}
+}
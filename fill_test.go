@@ -0,0 +1,27 @@
+package graphos
+
+import "testing"
+
+func TestFloodFill(t *testing.T) {
+	s := NewSurface(200, 200)
+	s.CurrentColor = white
+	s.DrawCircle(100, 100, 60)
+	s.DrawBox(10, 10, 50, 50)
+	s.FloodFill(100, 100, Color{255, 0, 0, 255})
+	if p := s.img.RGBAAt(100, 100); p.R != 255 || p.G != 0 {
+		t.Errorf("inside of the circle is %v", p)
+	}
+	if p := s.img.RGBAAt(30, 30); p.A != 0 {
+		t.Errorf("inside of the box is %v", p)
+	}
+	if p := s.img.RGBAAt(100, 170); p.A != 0 {
+		t.Errorf("outside of the circle is %v", p)
+	}
+
+	// a large fill must not recurse
+	big := NewSurface(2000, 2000)
+	big.FloodFill8(0, 0, white)
+	if p := big.img.RGBAAt(1999, 1999); p.A != 255 {
+		t.Errorf("far corner is %v", p)
+	}
+}
@@ -2,7 +2,10 @@ module crg.eti.br/go/graphos
 
 go 1.22
 
-require github.com/hajimehoshi/ebiten/v2 v2.7.7
+require (
+	github.com/hajimehoshi/ebiten/v2 v2.7.7
+	golang.org/x/image v0.18.0
+)
 
 require (
 	github.com/ebitengine/gomobile v0.0.0-20240518074828-e86332849895 // indirect
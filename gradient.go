@@ -0,0 +1,130 @@
+package graphos
+
+import "math"
+
+// ColorStop is a colour at Offset (0 to 1) along a gradient.
+type ColorStop struct {
+	Offset float64
+	Color  Color
+}
+
+// LinearGradient paints from X0, Y0 to X1, Y1. Stops must be in increasing
+// offset order; pixels before the first or after the last stop take its
+// colour.
+type LinearGradient struct {
+	X0, Y0, X1, Y1 float64
+	Stops          []ColorStop
+}
+
+// RadialGradient paints outward from the centre X, Y to radius R.
+type RadialGradient struct {
+	X, Y, R float64
+	Stops   []ColorStop
+}
+
+func (g LinearGradient) ColorAt(x, y int, base Color) (Color, bool) {
+	d := vec{g.X1 - g.X0, g.Y1 - g.Y0}
+	n := d.dot(d)
+	if n == 0 {
+		return stopColor(g.Stops, 0, base), true
+	}
+	t := center(x, y).sub(vec{g.X0, g.Y0}).dot(d) / n
+	return stopColor(g.Stops, t, base), true
+}
+
+func (g RadialGradient) ColorAt(x, y int, base Color) (Color, bool) {
+	if g.R <= 0 {
+		return stopColor(g.Stops, 1, base), true
+	}
+	t := center(x, y).sub(vec{g.X, g.Y}).len() / g.R
+	return stopColor(g.Stops, t, base), true
+}
+
+// stopColor interpolates the colour at t, falling back to base when there
+// are no stops.
+func stopColor(stops []ColorStop, t float64, base Color) Color {
+	if len(stops) == 0 {
+		return base
+	}
+	if t <= stops[0].Offset {
+		return stops[0].Color
+	}
+	for j := 1; j < len(stops); j++ {
+		a, b := stops[j-1], stops[j]
+		if t > b.Offset {
+			continue
+		}
+		if b.Offset == a.Offset {
+			return b.Color
+		}
+		k := (t - a.Offset) / (b.Offset - a.Offset)
+		var c Color
+		for n := range c {
+			c[n] = uint8(float64(a.Color[n]) + k*(float64(b.Color[n])-float64(a.Color[n])) + 0.5)
+		}
+		return c
+	}
+	return stops[len(stops)-1].Color
+}
+
+// Dither quantizes another paint to a palette with 4x4 ordered (Bayer)
+// dithering, so gradients keep their shape on a few colours. A nil Palette
+// means Colors16.
+type Dither struct {
+	Paint   Paint
+	Palette []Color
+}
+
+var bayer4 = [4][4]float64{
+	{0, 8, 2, 10},
+	{12, 4, 14, 6},
+	{3, 11, 1, 9},
+	{15, 7, 13, 5},
+}
+
+// ditherSpread is the per-channel step between the levels of Colors16.
+const ditherSpread = 85
+
+func (d Dither) ColorAt(x, y int, base Color) (Color, bool) {
+	c, ok := base, true
+	if d.Paint != nil {
+		c, ok = d.Paint.ColorAt(x, y, base)
+		if !ok {
+			return c, false
+		}
+	}
+	palette := d.Palette
+	if palette == nil {
+		palette = Colors16
+	}
+	if len(palette) == 0 {
+		return c, true
+	}
+
+	off := ditherOffset(x, y)
+	r := float64(c[0]) + off
+	g := float64(c[1]) + off
+	b := float64(c[2]) + off
+
+	q := palette[nearest(palette, r, g, b)]
+	q[3] = c[3]
+	return q, true
+}
+
+// ditherOffset is the ordered dithering threshold of pixel x, y, added to
+// each channel before picking the nearest palette colour.
+func ditherOffset(x, y int) float64 {
+	return ((bayer4[y&3][x&3]+0.5)/16 - 0.5) * ditherSpread
+}
+
+// nearest returns the index of the palette colour closest to r, g, b.
+func nearest(palette []Color, r, g, b float64) int {
+	best, dist := 0, math.Inf(1)
+	for j, p := range palette {
+		dr, dg, db := r-float64(p[0]), g-float64(p[1]), b-float64(p[2])
+		if e := dr*dr + dg*dg + db*db; e < dist {
+			best, dist = j, e
+		}
+	}
+	return best
+}
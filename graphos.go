@@ -1,7 +1,9 @@
 package graphos
 
 import (
+	"image"
 	"math"
+	"sort"
 )
 
 func Distance(x0, y0, x1, y1 int) int {
@@ -25,64 +27,153 @@ func abs(n int) int {
 	return (n ^ y) - y
 }
 
-func (p *Instance) DrawLine(x0, y0, x1, y1 int) {
-	dx := abs(x1 - x0)
-	dy := abs(y1 - y0)
-	sx := int(-1)
-	if x0 < x1 {
-		sx = 1
+func (p *Surface) DrawLine(x0, y0, x1, y1 int) {
+	if p.thick() {
+		p.strokeThick([]vec{center(x0, y0), center(x1, y1)}, false)
+		return
 	}
-	sy := int(-1)
-	if y0 < y1 {
-		sy = 1
+	p.drawSteps(x0, y0, x1, y1, 0, max(abs(x1-x0), abs(y1-y0)))
+}
+
+// drawSteps draws steps first to last (see bresenhamSteps) of the one pixel
+// wide line from x0, y0 to x1, y1, with the line pattern starting at step 0.
+func (p *Surface) drawSteps(x0, y0, x1, y1, first, last int) {
+	lo, hi, ok := p.lineSteps(x0, y0, x1, y1)
+	first, last = max(first, lo), min(last, hi)
+	if !ok || first > last {
+		return
 	}
-	e := dx - dy
+	patterned := p.patterned()
+	bresenhamSteps(x0, y0, x1, y1, first, last, func(x, y, k int) {
+		if !patterned || p.dashOn(k) {
+			p.DrawPix(x, y, p.CurrentColor)
+		}
+	})
+}
 
-	for {
-		p.DrawPix(int(x0), int(y0), p.CurrentColor)
+// bresenham calls plot for every pixel of the line from x0, y0 to x1, y1.
+func bresenham(x0, y0, x1, y1 int, plot func(x, y int)) {
+	n := max(abs(x1-x0), abs(y1-y0))
+	bresenhamSteps(x0, y0, x1, y1, 0, n, func(x, y, _ int) {
+		plot(x, y)
+	})
+}
 
-		if x0 == x1 && y0 == y1 {
-			return
+// bresenhamSteps calls plot for steps first to last of the line from x0, y0
+// to x1, y1, where step k is the pixel k steps along the major axis. The
+// pixels are those of the whole line whatever step it starts at, so
+// clipped lines match unclipped ones.
+func bresenhamSteps(x0, y0, x1, y1, first, last int, plot func(x, y, k int)) {
+	dx, dy := abs(x1-x0), abs(y1-y0)
+	sx, sy := 1, 1
+	if x1 < x0 {
+		sx = -1
+	}
+	if y1 < y0 {
+		sy = -1
+	}
+	// a and b are the minor and major deltas
+	a, b := dy, dx
+	if dy > dx {
+		a, b = dx, dy
+	}
+	if b == 0 {
+		if first <= 0 && last >= 0 {
+			plot(x0, y0, 0)
 		}
-		var e2 int = 2 * e
-		if e2 > -dy {
-			e = e - dy
-			x0 = x0 + sx
+		return
+	}
+
+	// the minor offset of step k is floor((2ak + b - 1) / 2b); m is the
+	// offset and r the remainder
+	num := 2*a*first + b - 1
+	m := floorDiv(num, 2*b)
+	r := num - 2*b*m
+	for k := first; k <= last; k++ {
+		if dy > dx {
+			plot(x0+sx*m, y0+sy*k, k)
+		} else {
+			plot(x0+sx*k, y0+sy*m, k)
 		}
-		if e2 < dx {
-			e = e + dx
-			y0 = y0 + sy
+		r += 2 * a
+		if r >= 2*b {
+			r -= 2 * b
+			m++
 		}
 	}
 }
 
-func (p *Instance) DrawBox(x1, y1, x2, y2 int) {
-
-	for y := y1; y <= y2; y++ {
-		p.DrawPix(x1, y, p.CurrentColor)
-		p.DrawPix(x2, y, p.CurrentColor)
+func (p *Surface) DrawBox(x1, y1, x2, y2 int) {
+	defer p.once()()
+	if p.thick() {
+		p.strokeThick([]vec{center(x1, y1), center(x2, y1), center(x2, y2), center(x1, y2)}, true)
+		return
 	}
-	for x := x1; x <= x2; x++ {
-		p.DrawPix(x, y1, p.CurrentColor)
-		p.DrawPix(x, y2, p.CurrentColor)
+	if p.patterned() {
+		p.drawPolyline([]image.Point{{x1, y1}, {x2, y1}, {x2, y2}, {x1, y2}}, true)
+		return
 	}
+	p.vline(x1, y1, y2, p.CurrentColor)
+	p.vline(x2, y1, y2, p.CurrentColor)
+	p.hline(x1, x2, y1, p.CurrentColor)
+	p.hline(x1, x2, y2, p.CurrentColor)
 }
 
-func (p *Instance) DrawCircle(x0, y0, radius int) {
-	x := radius
-	y := 0
-	e := 0
+func (p *Surface) DrawCircle(x0, y0, radius int) {
+	defer p.once()()
+	bounds := image.Rect(x0-radius, y0-radius, x0+radius+1, y0+radius+1)
+	if !bounds.Overlaps(p.clip) {
+		return
+	}
+	if p.patterned() {
+		p.dashCircle(x0, y0, radius)
+		return
+	}
+	if bounds.In(p.clip) {
+		circleOctant(radius, 0, radius, func(x, y int) {
+			p.setPix(x0+x, y0+y, p.CurrentColor)
+			p.setPix(x0+y, y0+x, p.CurrentColor)
+			p.setPix(x0-y, y0+x, p.CurrentColor)
+			p.setPix(x0-x, y0+y, p.CurrentColor)
+			p.setPix(x0-x, y0-y, p.CurrentColor)
+			p.setPix(x0-y, y0-x, p.CurrentColor)
+			p.setPix(x0+y, y0-x, p.CurrentColor)
+			p.setPix(x0+x, y0-y, p.CurrentColor)
+		})
+		return
+	}
 
-	for x >= y {
+	// only the steps that can reach the clip rectangle are walked: y is a
+	// row offset for four of the points and a column offset for the others
+	c := p.clip
+	lo, hi := offsetRange(y0, c.Min.Y, c.Max.Y-1)
+	circleOctant(radius, lo, hi, func(x, y int) {
 		p.DrawPix(x0+x, y0+y, p.CurrentColor)
-		p.DrawPix(x0+y, y0+x, p.CurrentColor)
-		p.DrawPix(x0-y, y0+x, p.CurrentColor)
 		p.DrawPix(x0-x, y0+y, p.CurrentColor)
 		p.DrawPix(x0-x, y0-y, p.CurrentColor)
+		p.DrawPix(x0+x, y0-y, p.CurrentColor)
+	})
+	lo, hi = offsetRange(x0, c.Min.X, c.Max.X-1)
+	circleOctant(radius, lo, hi, func(x, y int) {
+		p.DrawPix(x0+y, y0+x, p.CurrentColor)
+		p.DrawPix(x0-y, y0+x, p.CurrentColor)
 		p.DrawPix(x0-y, y0-x, p.CurrentColor)
 		p.DrawPix(x0+y, y0-x, p.CurrentColor)
-		p.DrawPix(x0+x, y0-y, p.CurrentColor)
+	})
+}
 
+// circleOctant calls plot for the points x >= y of the octant of the
+// circle of radius r, with y from lo to hi. The steps are those of the
+// midpoint walk from x = r, y = 0, started directly at row lo.
+func circleOctant(r, lo, hi int, plot func(x, y int)) {
+	if lo < 0 {
+		lo = 0
+	}
+	x, y := circleRow(r, lo), lo
+	// e is x² + (y+1)² - r² - 1, which the walk keeps near zero
+	e := x*x + (y+1)*(y+1) - r*r - 1
+	for x >= y && y <= hi {
+		plot(x, y)
 		if e <= 0 {
 			y += 1
 			e += 2*y + 1
@@ -94,35 +185,82 @@ func (p *Instance) DrawCircle(x0, y0, radius int) {
 	}
 }
 
-func (p *Instance) DrawFilledCircle(x0, y0, radius int) {
-	x := radius
-	y := 0
-	xChange := 1 - (radius << 1)
-	yChange := 0
-	radiusError := 0
+// circleRow returns the first x the octant walk of circleOctant reaches on
+// row y.
+func circleRow(r, y int) int {
+	x := isqrt(r*r + 1 - y*y)
+	if x*x+(y+1)*(y+1) > r*r+1 {
+		x--
+	}
+	return x
+}
 
-	for x >= y {
-		for i := x0 - x; i <= x0+x; i++ {
-			p.DrawPix(i, y0+y, p.CurrentColor)
-			p.DrawPix(i, y0-y, p.CurrentColor)
-		}
-		for i := x0 - y; i <= x0+y; i++ {
-			p.DrawPix(i, y0+x, p.CurrentColor)
-			p.DrawPix(i, y0-x, p.CurrentColor)
-		}
+// circleExtent returns the largest dx of the DrawCircle outline on row dy
+// (0 <= dy <= r), without walking the circle.
+func circleExtent(r, dy int) int {
+	// the octant point on row dy, if any, or else the mirror of the last
+	// octant point in column dy
+	a := circleRow(r, dy)
+	if a < dy {
+		a = -1
+	}
+	n := sort.Search(r+1, func(y int) bool { return circleRow(r, y) < dy })
+	return max(a, min(n-1, dy))
+}
 
-		y++
-		radiusError += yChange
-		yChange += 2
-		if ((radiusError << 1) + xChange) > 0 {
-			x--
-			radiusError += xChange
-			xChange += 2
-		}
+// isqrt returns the integer square root of n, or -1 if n is negative.
+func isqrt(n int) int {
+	if n < 0 {
+		return -1
+	}
+	x := int(math.Sqrt(float64(n)))
+	for x*x > n {
+		x--
+	}
+	for (x+1)*(x+1) <= n {
+		x++
 	}
+	return x
 }
 
-func (p *Instance) DrawFilledBox(x1, y1, x2, y2 int, color Color) {
+// offsetRange returns the range of d >= 0 for which c+d or c-d is within
+// lo..hi.
+func offsetRange(c, lo, hi int) (int, int) {
+	a, b := lo-c, hi-c
+	switch {
+	case a <= 0 && b >= 0:
+		return 0, max(-a, b)
+	case b < 0:
+		return -b, -a
+	}
+	return a, b
+}
+
+// DrawFilledCircle fills the circle DrawCircle outlines. Only the rows
+// inside the clip rectangle are visited.
+func (p *Surface) DrawFilledCircle(x0, y0, radius int) {
+	defer p.once()()
+	if radius < 0 {
+		return
+	}
+	for y := max(y0-radius, p.clip.Min.Y); y <= min(y0+radius, p.clip.Max.Y-1); y++ {
+		w := circleExtent(radius, abs(y-y0))
+		p.fillSpan(x0-w, x0+w, y, p.CurrentColor)
+	}
+}
+
+func (p *Surface) DrawFilledBox(x1, y1, x2, y2 int, color Color) {
+	r := image.Rect(x1, y1, x2+1, y2+1).Intersect(p.clip)
+	if r.Empty() {
+		return
+	}
+	x1, y1, x2, y2 = r.Min.X, r.Min.Y, r.Max.X-1, r.Max.Y-1
+	if p.FillStyle != nil || !p.opaque(color) {
+		for y := y1; y <= y2; y++ {
+			p.fillSpan(x1, x2, y, color)
+		}
+		return
+	}
 	pix := p.img.Pix
 
 	array := make([]byte, 4*(x2-x1+1))
@@ -130,6 +268,8 @@ func (p *Instance) DrawFilledBox(x1, y1, x2, y2 int, color Color) {
 		copy(array[i:], color[:])
 	}
 
+	p.damage(image.Rect(x1, y1, x2+1, y2+1))
+
 	x1 = 4 * x1
 
 	for y := y1; y <= y2; y++ {
@@ -0,0 +1,34 @@
+package graphos
+
+import (
+	"image"
+	"testing"
+)
+
+func TestFilledCircleMatchesOutline(t *testing.T) {
+	for r := 0; r < 40; r++ {
+		a, b := NewSurface(84, 84), NewSurface(84, 84)
+		a.CurrentColor, b.CurrentColor = white, white
+		a.DrawCircle(42, 42, r)
+		b.DrawFilledCircle(42, 42, r)
+
+		// widest outline pixel of each row
+		outline := map[int]int{}
+		for p := range lit(a) {
+			outline[p.Y] = max(outline[p.Y], abs(p.X-42))
+		}
+		filled := lit(b)
+		for y, dx := range outline {
+			for x := 42 - dx; x <= 42+dx; x++ {
+				if !filled[image.Pt(x, y)] {
+					t.Fatalf("radius %v: pixel %v,%v inside the outline is not filled", r, x, y)
+				}
+			}
+		}
+		for p := range filled {
+			if dx, ok := outline[p.Y]; !ok || abs(p.X-42) > dx {
+				t.Fatalf("radius %v: pixel %v is filled outside the outline", r, p)
+			}
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package graphos
+
+import (
+	"errors"
+	"io"
+)
+
+// HeadlessBackend runs an instance without a window, input or sound. It
+// implements VideoBackend, InputBackend and AudioBackend, so it can also
+// serve as a base for fakes in tests.
+type HeadlessBackend struct {
+	// Frames is the number of ticks Run executes; zero runs until
+	// Running is set to false.
+	Frames int
+}
+
+// Run steps the instance until Frames ticks have run, ScreenHandler
+// returns an error or Running is set to false.
+func (b *HeadlessBackend) Run(i *Instance) error {
+	for n := 0; b.Frames == 0 || n < b.Frames; n++ {
+		err := i.Step()
+		if err != nil {
+			return err
+		}
+		if !i.Running {
+			break
+		}
+	}
+	return nil
+}
+
+func (b *HeadlessBackend) IsKeyPressed(key Key) bool                         { return false }
+func (b *HeadlessBackend) IsKeyJustPressed(key Key) bool                     { return false }
+func (b *HeadlessBackend) IsKeyJustReleased(key Key) bool                    { return false }
+func (b *HeadlessBackend) IsMouseButtonPressed(button MouseButton) bool      { return false }
+func (b *HeadlessBackend) IsMouseButtonJustPressed(button MouseButton) bool  { return false }
+func (b *HeadlessBackend) IsMouseButtonJustReleased(button MouseButton) bool { return false }
+func (b *HeadlessBackend) CursorPosition() (int, int)                        { return 0, 0 }
+func (b *HeadlessBackend) AppendInputChars(runes []rune) []rune              { return runes }
+
+func (b *HeadlessBackend) LoadWavLoop(r io.Reader) error { return nil }
+func (b *HeadlessBackend) Play()                         {}
+func (b *HeadlessBackend) Pause()                        {}
+
+// Step runs one tick of the game loop without a window and presents a
+// pending frame to the in-memory framebuffer. The clock advances by exactly
+// one tick, so runs are reproducible. Init must be called first.
+func (i *Instance) Step() error {
+	if i.img == nil {
+		return errors.New("graphos: Step called before Init")
+	}
+	i.headless()
+	err := i.advance(i.TickDuration())
+	if err != nil {
+		return err
+	}
+	i.mu.Lock()
+	i.Present()
+	i.mu.Unlock()
+	return nil
+}
+
+// RunHeadless initializes the instance and runs the given number of frames
+// without opening a window; zero or less runs none. It stops early if
+// ScreenHandler returns an error or sets Running to false.
+func (i *Instance) RunHeadless(frames int) error {
+	i.headless()
+	i.Init()
+	if frames <= 0 {
+		return nil
+	}
+	i.setRunning(true)
+	err := (&HeadlessBackend{Frames: frames}).Run(i)
+	i.setRunning(false)
+	return err
+}
+
+// headless replaces the input and audio backends New installed with
+// HeadlessBackend, so headless runs never touch the window or sound card.
+// Backends set by the caller are kept.
+func (i *Instance) headless() {
+	var h HeadlessBackend
+	if i.InputDevice == i.defaultBackend {
+		i.InputDevice = &h
+	}
+	if i.Audio == i.defaultBackend {
+		i.Audio = &h
+	}
+}
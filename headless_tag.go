@@ -0,0 +1,8 @@
+//go:build headless
+
+package graphos
+
+// newBackend returns HeadlessBackend when built with the headless tag.
+func newBackend() backend {
+	return &HeadlessBackend{}
+}
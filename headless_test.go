@@ -0,0 +1,116 @@
+package graphos
+
+import (
+	"bytes"
+	"flag"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden images in testdata")
+
+// golden compares img with testdata/name, rewriting it with -update.
+func golden(t *testing.T, name string, img image.Image) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	var buf bytes.Buffer
+	err := png.Encode(&buf, img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *update {
+		err = os.WriteFile(path, buf.Bytes(), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	want, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want.Bounds() != img.Bounds() {
+		t.Fatalf("%v: bounds %v, want %v", name, img.Bounds(), want.Bounds())
+	}
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r0, g0, b0, a0 := img.At(x, y).RGBA()
+			r1, g1, b1, a1 := want.At(x, y).RGBA()
+			if r0 != r1 || g0 != g1 || b0 != b1 || a0 != a1 {
+				t.Fatalf("%v: pixel %v,%v differs from the golden image", name, x, y)
+			}
+		}
+	}
+}
+
+func scene(i *Instance) error {
+	if i.UTime == 0 {
+		i.CurrentColor = Colors16[1]
+		i.Clear()
+	}
+	i.CurrentColor = Colors16[2+i.UTime%14]
+	i.DrawCircle(40+int(i.UTime)*20, 60, 15)
+	i.DrawLine(0, 0, int(i.UTime)*30, 199)
+	i.DrawString("graphos", 0x0F, 0x01, 8, 150)
+	i.UpdateScreen = true
+	return nil
+}
+
+func TestRunHeadless(t *testing.T) {
+	i := New()
+	i.Width, i.Height = 320, 200
+	i.ScreenHandler = scene
+	err := i.RunHeadless(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i.Frames != 10 {
+		t.Fatalf("presented %v frames, want 10", i.Frames)
+	}
+	golden(t, "headless.png", i.Snapshot())
+}
+
+func TestStep(t *testing.T) {
+	i := New()
+	i.Width, i.Height = 320, 200
+	i.ScreenHandler = scene
+	i.Init()
+	for n := 0; n < 10; n++ {
+		err := i.Step()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if i.Elapsed != 10*i.TickDuration() {
+		t.Fatalf("elapsed %v, want 10 ticks", i.Elapsed)
+	}
+	golden(t, "headless.png", i.Snapshot())
+}
+
+func TestStepBeforeInit(t *testing.T) {
+	i := New()
+	if i.Step() == nil {
+		t.Fatal("Step before Init did not fail")
+	}
+}
+
+func TestRunHeadlessZeroFrames(t *testing.T) {
+	i := New()
+	i.ScreenHandler = func(*Instance) error {
+		t.Fatal("ScreenHandler called")
+		return nil
+	}
+	err := i.RunHeadless(0)
+	if err != nil || i.UTime != 0 {
+		t.Fatalf("RunHeadless(0) ran %v ticks, err %v", i.UTime, err)
+	}
+}
@@ -0,0 +1,325 @@
+package graphos
+
+import (
+	"image"
+	"math"
+)
+
+type LineCap int
+
+const (
+	CapButt LineCap = iota
+	CapRound
+	CapSquare
+)
+
+type LineJoin int
+
+const (
+	JoinMiter LineJoin = iota
+	JoinRound
+	JoinBevel
+)
+
+// miterLimit is the longest miter, in half line widths, before a miter
+// join falls back to a bevel.
+const miterLimit = 4
+
+type vec struct {
+	x, y float64
+}
+
+func (a vec) add(b vec) vec       { return vec{a.x + b.x, a.y + b.y} }
+func (a vec) sub(b vec) vec       { return vec{a.x - b.x, a.y - b.y} }
+func (a vec) mul(k float64) vec   { return vec{a.x * k, a.y * k} }
+func (a vec) dot(b vec) float64   { return a.x*b.x + a.y*b.y }
+func (a vec) cross(b vec) float64 { return a.x*b.y - a.y*b.x }
+func (a vec) len() float64        { return math.Hypot(a.x, a.y) }
+
+// center returns the centre of pixel x, y in continuous coordinates.
+func center(x, y int) vec {
+	return vec{float64(x) + 0.5, float64(y) + 0.5}
+}
+
+func (s *Surface) thick() bool {
+	return s.LineWidth > 1
+}
+
+// DrawPolyline draws connected line segments through pts with the current
+// line width, caps and joins. With a width of one pixel or less it is the
+// same as calling DrawLine for each segment.
+func (s *Surface) DrawPolyline(pts []image.Point) {
+	s.drawPolyline(pts, false)
+}
+
+func (s *Surface) drawPolyline(pts []image.Point, closed bool) {
+	defer s.once()()
+	if len(pts) == 0 {
+		return
+	}
+	if !s.thick() && s.patterned() {
+		n := 0
+		for j := 1; j < len(pts); j++ {
+			n = s.dashLine(pts[j-1].X, pts[j-1].Y, pts[j].X, pts[j].Y, n)
+		}
+		if closed && len(pts) > 2 {
+			s.dashLine(pts[len(pts)-1].X, pts[len(pts)-1].Y, pts[0].X, pts[0].Y, n)
+		}
+		return
+	}
+	if !s.thick() {
+		for j := 1; j < len(pts); j++ {
+			s.DrawLine(pts[j-1].X, pts[j-1].Y, pts[j].X, pts[j].Y)
+		}
+		if closed && len(pts) > 2 {
+			s.DrawLine(pts[len(pts)-1].X, pts[len(pts)-1].Y, pts[0].X, pts[0].Y)
+		}
+		return
+	}
+	v := make([]vec, len(pts))
+	for j, pt := range pts {
+		v[j] = center(pt.X, pt.Y)
+	}
+	s.strokeThick(v, closed)
+}
+
+// strokeThick strokes the polyline v, in continuous coordinates, with
+// LineWidth, LineCap, LineJoin and LinePattern.
+func (s *Surface) strokeThick(v []vec, closed bool) {
+	defer s.once()()
+	if s.patterned() {
+		for _, d := range s.dashes(v, closed) {
+			s.strokeSolid(d, false)
+		}
+		return
+	}
+	s.strokeSolid(v, closed)
+}
+
+func (s *Surface) strokeSolid(v []vec, closed bool) {
+	// drop repeated points, they have no direction
+	pts := v[:0:0]
+	for _, p := range v {
+		if len(pts) == 0 || p != pts[len(pts)-1] {
+			pts = append(pts, p)
+		}
+	}
+	if closed && len(pts) > 1 && pts[0] == pts[len(pts)-1] {
+		pts = pts[:len(pts)-1]
+	}
+	hw := s.LineWidth / 2
+	if len(pts) == 1 {
+		if s.LineCap == CapRound {
+			s.fillDisc(pts[0], hw, s.CurrentColor)
+			return
+		}
+		if s.LineCap == CapSquare {
+			p := pts[0]
+			s.fillConvex([]vec{{p.x - hw, p.y - hw}, {p.x + hw, p.y - hw}, {p.x + hw, p.y + hw}, {p.x - hw, p.y + hw}}, s.CurrentColor)
+		}
+		return
+	}
+
+	n := len(pts) - 1
+	if closed {
+		n = len(pts)
+	}
+	for j := 0; j < n; j++ {
+		a, b := pts[j], pts[(j+1)%len(pts)]
+		if !closed && s.LineCap == CapSquare {
+			d := b.sub(a).mul(hw / b.sub(a).len())
+			if j == 0 {
+				a = a.sub(d)
+			}
+			if j == n-1 {
+				b = b.add(d)
+			}
+		}
+		s.fillSegment(a, b, hw, s.CurrentColor)
+	}
+
+	for j := 0; j < len(pts); j++ {
+		if !closed && (j == 0 || j == len(pts)-1) {
+			continue
+		}
+		prev := pts[(j+len(pts)-1)%len(pts)]
+		next := pts[(j+1)%len(pts)]
+		s.join(prev, pts[j], next, hw, s.CurrentColor)
+	}
+
+	if !closed && s.LineCap == CapRound {
+		s.fillDisc(pts[0], hw, s.CurrentColor)
+		s.fillDisc(pts[len(pts)-1], hw, s.CurrentColor)
+	}
+}
+
+// normal returns the unit normal of the segment a-b.
+func normal(a, b vec) vec {
+	d := b.sub(a)
+	l := d.len()
+	return vec{-d.y / l, d.x / l}
+}
+
+func (s *Surface) fillSegment(a, b vec, hw float64, c Color) {
+	nv := normal(a, b).mul(hw)
+	s.fillConvex([]vec{a.add(nv), b.add(nv), b.sub(nv), a.sub(nv)}, c)
+}
+
+// join fills the gap on the outer side of the corner at p between the
+// segments prev-p and p-next.
+func (s *Surface) join(prev, p, next vec, hw float64, c Color) {
+	if s.LineJoin == JoinRound {
+		s.fillDisc(p, hw, c)
+		return
+	}
+	na, nb := normal(prev, p), normal(p, next)
+	turn := next.sub(p).dot(na)
+	if math.Abs(turn) < 1e-9 {
+		return
+	}
+	side := -1.0
+	if turn < 0 {
+		side = 1
+	}
+	a := p.add(na.mul(side * hw))
+	b := p.add(nb.mul(side * hw))
+
+	if s.LineJoin == JoinMiter {
+		k := 1 + na.dot(nb)
+		if k > 1e-9 {
+			m := na.add(nb).mul(side * hw / k)
+			if m.len() <= miterLimit*hw {
+				s.fillConvex([]vec{p, a, p.add(m), b}, c)
+				return
+			}
+		}
+	}
+	s.fillConvex([]vec{p, a, b}, c)
+}
+
+// fillConvex fills the convex polygon pts, sampling pixel centres.
+func (s *Surface) fillConvex(pts []vec, c Color) {
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	for _, p := range pts {
+		minY = math.Min(minY, p.y)
+		maxY = math.Max(maxY, p.y)
+	}
+	y0 := int(math.Ceil(minY - 0.5))
+	y1 := int(math.Floor(maxY - 0.5))
+	if y0 < s.clip.Min.Y {
+		y0 = s.clip.Min.Y
+	}
+	if y1 >= s.clip.Max.Y {
+		y1 = s.clip.Max.Y - 1
+	}
+	for y := y0; y <= y1; y++ {
+		yc := float64(y) + 0.5
+		xl, xr := math.Inf(1), math.Inf(-1)
+		for j := range pts {
+			a, b := pts[j], pts[(j+1)%len(pts)]
+			if (a.y <= yc) == (b.y <= yc) {
+				continue
+			}
+			x := a.x + (yc-a.y)*(b.x-a.x)/(b.y-a.y)
+			xl = math.Min(xl, x)
+			xr = math.Max(xr, x)
+		}
+		if xl > xr {
+			continue
+		}
+		s.hline(int(math.Ceil(xl-0.5)), int(math.Floor(xr-0.5)), y, c)
+	}
+}
+
+// fillDisc fills the disc of radius r around p, sampling pixel centres.
+func (s *Surface) fillDisc(p vec, r float64, c Color) {
+	y0 := int(math.Ceil(p.y - r - 0.5))
+	y1 := int(math.Floor(p.y + r - 0.5))
+	for y := y0; y <= y1; y++ {
+		dy := float64(y) + 0.5 - p.y
+		dx := r*r - dy*dy
+		if dx < 0 {
+			continue
+		}
+		dx = math.Sqrt(dx)
+		x0 := int(math.Ceil(p.x - dx - 0.5))
+		x1 := int(math.Floor(p.x + dx - 0.5))
+		if x0 <= x1 {
+			s.hline(x0, x1, y, c)
+		}
+	}
+}
+
+// DrawLineAA draws an anti-aliased one pixel wide line with Xiaolin Wu's
+// algorithm. Integer coordinates are pixel centres, as in DrawLine.
+// Coverage is applied through the alpha channel, so the result depends on
+// the blend mode; use BlendOver.
+func (s *Surface) DrawLineAA(x0, y0, x1, y1 float64) {
+	plot := func(x, y int, cov float64) {
+		c := s.CurrentColor
+		c[3] = uint8(float64(c[3])*cov + 0.5)
+		if c[3] == 0 {
+			return
+		}
+		s.DrawPix(x, y, c)
+	}
+
+	steep := math.Abs(y1-y0) > math.Abs(x1-x0)
+	if steep {
+		x0, y0 = y0, x0
+		x1, y1 = y1, x1
+		p := plot
+		plot = func(x, y int, cov float64) { p(y, x, cov) }
+	}
+	if x0 > x1 {
+		x0, x1 = x1, x0
+		y0, y1 = y1, y0
+	}
+
+	dx := x1 - x0
+	dy := y1 - y0
+	gradient := 1.0
+	if dx != 0 {
+		gradient = dy / dx
+	}
+
+	fpart := func(v float64) float64 { return v - math.Floor(v) }
+	rfpart := func(v float64) float64 { return 1 - fpart(v) }
+
+	xend := math.Round(x0)
+	yend1 := y0 + gradient*(xend-x0)
+	xgap1 := rfpart(x0 + 0.5)
+	xpx1 := int(xend)
+
+	xend = math.Round(x1)
+	yend2 := y1 + gradient*(xend-x1)
+	xgap2 := fpart(x1 + 0.5)
+	xpx2 := int(xend)
+
+	end := func(x int, yend, xgap float64) {
+		y := int(math.Floor(yend))
+		plot(x, y, rfpart(yend)*xgap)
+		plot(x, y+1, fpart(yend)*xgap)
+	}
+	if xpx1 == xpx2 {
+		// both ends are in the same column, which is plotted once
+		end(xpx1, yend1, math.Min(xgap1+xgap2, 1))
+		return
+	}
+	end(xpx1, yend1, xgap1)
+	end(xpx2, yend2, xgap2)
+
+	// only the columns inside the clip rectangle are walked
+	lo, hi := s.clip.Min.X, s.clip.Max.X-1
+	if steep {
+		lo, hi = s.clip.Min.Y, s.clip.Max.Y-1
+	}
+	first, last := max(xpx1+1, lo), min(xpx2-1, hi)
+	intery := yend1 + gradient*float64(first-xpx1)
+	for x := first; x <= last; x++ {
+		y := int(math.Floor(intery))
+		plot(x, y, rfpart(intery))
+		plot(x, y+1, fpart(intery))
+		intery += gradient
+	}
+}
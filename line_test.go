@@ -0,0 +1,45 @@
+package graphos
+
+import (
+	"image"
+	"testing"
+	"time"
+)
+
+func TestDrawLineAAClipped(t *testing.T) {
+	s := NewSurface(40, 40)
+	s.CurrentColor = white
+	start := time.Now()
+	s.DrawLineAA(-1e8, 10, 1e8, 20)
+	if d := time.Since(start); d > 100*time.Millisecond {
+		t.Errorf("a huge clipped line took %v", d)
+	}
+
+	u, c := NewSurface(40, 40), NewSurface(40, 40)
+	u.CurrentColor, c.CurrentColor = white, white
+	clip := image.Rect(10, 0, 20, 40)
+	c.SetClip(clip)
+	u.DrawLineAA(2, 3, 30, 17)
+	c.DrawLineAA(2, 3, 30, 17)
+	for y := 0; y < 40; y++ {
+		for x := 0; x < 40; x++ {
+			want := u.img.RGBAAt(x, y)
+			if !(image.Point{x, y}).In(clip) {
+				want = c.img.RGBAAt(-1, -1)
+			}
+			if got := c.img.RGBAAt(x, y); got != want {
+				t.Fatalf("pixel %v,%v is %v, want %v", x, y, got, want)
+			}
+		}
+	}
+}
+
+func TestDrawLineAASinglePixel(t *testing.T) {
+	s := NewSurface(10, 10)
+	s.CurrentColor = white
+	s.Blend = XorPut
+	s.DrawLineAA(5, 5, 5, 5)
+	if p := s.img.RGBAAt(5, 5); p.R != 255 {
+		t.Fatalf("single pixel line under XOR gave %v", p)
+	}
+}
@@ -0,0 +1,155 @@
+package graphos
+
+import (
+	"image"
+	"math"
+)
+
+// BGI line patterns for LinePattern. Bits are used from the most
+// significant one, each LineScale pixels long (one when zero). LinePhase
+// shifts where the pattern starts on every line, box, circle, polyline,
+// polygon and path outline; the phase carries on across the segments of
+// one outline. Adding UTime to LinePhase every frame makes marching ants.
+// A zero LinePattern is solid.
+const (
+	SolidLine  uint16 = 0xFFFF
+	DottedLine uint16 = 0xCCCC
+	CenterLine uint16 = 0xFC78
+	DashedLine uint16 = 0xF8F8
+)
+
+func (s *Surface) patterned() bool {
+	return s.LinePattern != 0 && s.LinePattern != SolidLine
+}
+
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
+func (s *Surface) lineScale() int {
+	return max(s.LineScale, 1)
+}
+
+// dashBit reports whether bit k of the pattern, counted from the phase, is
+// set.
+func (s *Surface) dashBit(k int) bool {
+	if !s.patterned() {
+		return true
+	}
+	return s.LinePattern&(0x8000>>(k&15)) != 0
+}
+
+// dashOn reports whether the pixel n pixels along an outline is drawn.
+func (s *Surface) dashOn(n int) bool {
+	return s.dashBit(floorDiv(n+s.LinePhase, s.lineScale()))
+}
+
+// dashLine draws a one pixel wide patterned line whose first pixel is n
+// pixels along the outline and returns the position of its last pixel,
+// where the next connected segment starts.
+func (s *Surface) dashLine(x0, y0, x1, y1, n int) int {
+	end := n + max(abs(x1-x0), abs(y1-y0))
+	first, last, ok := s.lineSteps(x0, y0, x1, y1)
+	if !ok {
+		return end
+	}
+	bresenhamSteps(x0, y0, x1, y1, first, last, func(x, y, k int) {
+		if s.dashOn(n + k) {
+			s.DrawPix(x, y, s.CurrentColor)
+		}
+	})
+	return end
+}
+
+// dashCircle draws a patterned circle, numbering the pixels of the eight
+// octants so that the pattern runs around it clockwise from 3 o'clock.
+func (s *Surface) dashCircle(x0, y0, radius int) {
+	var oct []image.Point
+	x, y, e := radius, 0, 0
+	for x >= y {
+		oct = append(oct, image.Point{x, y})
+		if e <= 0 {
+			y++
+			e += 2*y + 1
+		}
+		if e > 0 {
+			x--
+			e -= 2*x + 1
+		}
+	}
+	if len(oct) == 0 {
+		return
+	}
+	// d is twice the position of the 45 degree point
+	d := 2*len(oct) - 1
+	if last := oct[len(oct)-1]; last.X == last.Y {
+		d--
+	}
+	plot := func(x, y, n int) {
+		if s.dashOn(n) {
+			s.DrawPix(x0+x, y0+y, s.CurrentColor)
+		}
+	}
+	for j, p := range oct {
+		plot(p.X, p.Y, j)
+		plot(p.Y, p.X, d-j)
+		plot(-p.Y, p.X, d+j)
+		plot(-p.X, p.Y, 2*d-j)
+		plot(-p.X, -p.Y, 2*d+j)
+		plot(-p.Y, -p.X, 3*d-j)
+		plot(p.Y, -p.X, 3*d+j)
+		if j > 0 {
+			plot(p.X, -p.Y, 4*d-j)
+		}
+	}
+}
+
+// dashes splits the polyline v into the runs drawn by the line pattern,
+// measuring the pattern along its length.
+func (s *Surface) dashes(v []vec, closed bool) [][]vec {
+	if closed && len(v) > 1 {
+		v = append(v[:len(v):len(v)], v[0])
+	}
+	scale := float64(s.lineScale())
+	phase := float64(s.LinePhase)
+
+	var out [][]vec
+	var run []vec
+	pos := 0.0
+	for j := 1; j < len(v); j++ {
+		a, b := v[j-1], v[j]
+		l := b.sub(a).len()
+		if l == 0 {
+			continue
+		}
+		dir := b.sub(a).mul(1 / l)
+		for t := 0.0; t < l; {
+			k := math.Floor((pos + t + phase) / scale)
+			next := math.Min((k+1)*scale-phase-pos, l)
+			if next <= t {
+				// rounding put t on the boundary
+				k++
+				next = math.Min((k+1)*scale-phase-pos, l)
+			}
+			if s.dashBit(int(k)) {
+				if len(run) == 0 {
+					run = append(run, a.add(dir.mul(t)))
+				}
+				run = append(run, a.add(dir.mul(next)))
+			} else if len(run) > 0 {
+				out = append(out, run)
+				run = nil
+			}
+			t = next
+		}
+		pos += l
+	}
+	if len(run) > 0 {
+		out = append(out, run)
+	}
+	return out
+}
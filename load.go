@@ -0,0 +1,178 @@
+package graphos
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	_ "image/png"
+	"io"
+	"io/fs"
+	"time"
+
+	_ "golang.org/x/image/bmp"
+)
+
+// LoadOptions control how images are decoded into sprites. When Palette is
+// set, for example to Colors16, the image is quantized to it, using at
+// most 255 colours, and the sprite is indexed; Dither uses ordered
+// dithering instead of the plain nearest colour. Transparent pixels get an
+// extra transparent palette entry at the end.
+type LoadOptions struct {
+	Palette []Color
+	Dither  bool
+}
+
+// Animation is the frames of an animated GIF and how long each is shown.
+type Animation struct {
+	Frames []*Sprite
+	Delays []time.Duration
+}
+
+// LoadSprite decodes the PNG, GIF or BMP file name from fsys, such as an
+// embed.FS. opt may be nil.
+func LoadSprite(fsys fs.FS, name string, opt *LoadOptions) (*Sprite, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("error opening %v: %v", name, err)
+	}
+	defer f.Close()
+	sp, err := DecodeSprite(f, opt)
+	if err != nil {
+		return nil, fmt.Errorf("%v, error: %v", name, err)
+	}
+	return sp, nil
+}
+
+// DecodeSprite decodes a PNG, GIF (its first frame) or BMP image.
+func DecodeSprite(r io.Reader, opt *LoadOptions) (*Sprite, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding image: %v", err)
+	}
+	return newSprite(img, opt), nil
+}
+
+// LoadSurface decodes the PNG, GIF or BMP file name from fsys into a new
+// surface.
+func LoadSurface(fsys fs.FS, name string) (*Surface, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("error opening %v: %v", name, err)
+	}
+	defer f.Close()
+	s, err := DecodeSurface(f)
+	if err != nil {
+		return nil, fmt.Errorf("%v, error: %v", name, err)
+	}
+	return s, nil
+}
+
+// DecodeSurface decodes a PNG, GIF (its first frame) or BMP image into a
+// new surface.
+func DecodeSurface(r io.Reader) (*Surface, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding image: %v", err)
+	}
+	b := img.Bounds()
+	s := NewSurface(b.Dx(), b.Dy())
+	draw.Draw(s.img, s.img.Rect, img, b.Min, draw.Src)
+	return s, nil
+}
+
+// LoadAnimation decodes every frame of the GIF file name from fsys. Other
+// formats give a single frame.
+func LoadAnimation(fsys fs.FS, name string, opt *LoadOptions) (*Animation, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("error opening %v: %v", name, err)
+	}
+	defer f.Close()
+	a, err := DecodeAnimation(f, opt)
+	if err != nil {
+		return nil, fmt.Errorf("%v, error: %v", name, err)
+	}
+	return a, nil
+}
+
+// DecodeAnimation decodes every frame of a GIF, composed with the frame
+// disposal methods so each frame is a complete picture.
+func DecodeAnimation(r io.Reader, opt *LoadOptions) (*Animation, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(4)
+	if string(magic) != "GIF8" {
+		sp, err := DecodeSprite(br, opt)
+		if err != nil {
+			return nil, err
+		}
+		return &Animation{Frames: []*Sprite{sp}, Delays: []time.Duration{0}}, nil
+	}
+
+	g, err := gif.DecodeAll(br)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding gif: %v", err)
+	}
+	canvas := image.NewRGBA(image.Rect(0, 0, g.Config.Width, g.Config.Height))
+	var prev *image.RGBA
+	a := &Animation{}
+	for j, frame := range g.Image {
+		disposal := byte(0)
+		if j < len(g.Disposal) {
+			disposal = g.Disposal[j]
+		}
+		if disposal == gif.DisposalPrevious {
+			prev = image.NewRGBA(canvas.Rect)
+			copy(prev.Pix, canvas.Pix)
+		}
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+		a.Frames = append(a.Frames, newSprite(canvas, opt))
+		a.Delays = append(a.Delays, time.Duration(g.Delay[j])*10*time.Millisecond)
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = prev
+		}
+	}
+	return a, nil
+}
+
+// newSprite copies img into a sprite, quantizing it when opt asks to.
+func newSprite(img image.Image, opt *LoadOptions) *Sprite {
+	if opt == nil || opt.Palette == nil {
+		return SpriteFromImage(img)
+	}
+
+	b := img.Bounds()
+	palette := opt.Palette
+	if len(palette) > 255 {
+		palette = palette[:255]
+	}
+	n := len(palette)
+	transparent := -1
+	sp := NewIndexedSprite(b.Dx(), b.Dy(), palette)
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			c := toColor(img.At(b.Min.X+x, b.Min.Y+y))
+			if c[3] < 0x80 {
+				if transparent < 0 {
+					transparent = n
+					palette = append(palette[:n:n], Color{})
+				}
+				sp.Indexes[y*sp.Width+x] = uint8(transparent)
+				continue
+			}
+			r, g, bl := float64(c[0]), float64(c[1]), float64(c[2])
+			if opt.Dither {
+				off := ditherOffset(x, y)
+				r, g, bl = r+off, g+off, bl+off
+			}
+			sp.Indexes[y*sp.Width+x] = uint8(nearest(palette[:n], r, g, bl))
+		}
+	}
+	sp.Palette = palette
+	return sp
+}
@@ -0,0 +1,577 @@
+package graphos
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxModules stops L-systems that grow out of hand.
+const maxModules = 1 << 22
+
+// LSystem is a Lindenmayer system drawn with its own turtle cursor. The
+// axiom is rewritten Iterations times by Rules, then each symbol of the
+// result is drawn:
+//
+//	F, G  move forward drawing a line
+//	f     move forward without drawing
+//	+, -  turn left, right by Angle degrees
+//	|     turn around
+//	[, ]  push, pop the position and heading
+//
+// Other symbols are only used for rewriting. In a parametric system the
+// first parameter of F, G and f is the step length and that of + and - the
+// angle, so "F(10)" draws 10 pixels regardless of Step.
+type LSystem struct {
+	Axiom      string
+	Rules      []Rule
+	Iterations int
+	Angle      float64
+	Step       float64
+	// Rand picks among stochastic rules; nil uses math/rand.
+	Rand *rand.Rand
+}
+
+// Rule rewrites the predecessor symbol Pred, for example "F" or, with
+// parameters, "A(l,w)", into Succ, whose parameters are expressions of
+// those of Pred: "F(l)[+A(l*0.7,w/2)]". Cond, when set, is an expression
+// that must be true for the rule to apply, such as "l > 1". Expressions
+// use numbers, the parameter names, + - * / ^, comparisons, && || and !.
+//
+// When several rules match a symbol, one is picked at random in
+// proportion to Weight (zero means 1), which makes the system stochastic.
+type Rule struct {
+	Pred   string
+	Cond   string
+	Succ   string
+	Weight float64
+}
+
+// module is a symbol with its parameters.
+type module struct {
+	sym    rune
+	params []float64
+}
+
+// LineDrawer is where an L-system is drawn, such as an Instance or a
+// Surface.
+type LineDrawer interface {
+	DrawLine(x0, y0, x1, y1 int)
+}
+
+// Expand returns the result of rewriting the axiom Iterations times.
+func (l *LSystem) Expand() (string, error) {
+	mods, err := l.expand()
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, m := range mods {
+		b.WriteRune(m.sym)
+		if len(m.params) == 0 {
+			continue
+		}
+		b.WriteByte('(')
+		for j, p := range m.params {
+			if j > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(strconv.FormatFloat(p, 'g', -1, 64))
+		}
+		b.WriteByte(')')
+	}
+	return b.String(), nil
+}
+
+// Draw expands the system and draws it on d starting at x, y facing
+// heading degrees, counterclockwise from three o'clock.
+func (l *LSystem) Draw(d LineDrawer, x, y, heading float64) error {
+	mods, err := l.expand()
+	if err != nil {
+		return err
+	}
+
+	type state struct{ x, y, heading float64 }
+	cur := state{x, y, heading}
+	var stack []state
+	for _, m := range mods {
+		switch m.sym {
+		case 'F', 'G', 'f':
+			step := l.Step
+			if len(m.params) > 0 {
+				step = m.params[0]
+			}
+			sin, cos := math.Sincos(cur.heading * math.Pi / 180)
+			nx, ny := cur.x+step*cos, cur.y-step*sin
+			if m.sym != 'f' {
+				d.DrawLine(int(math.Round(cur.x)), int(math.Round(cur.y)), int(math.Round(nx)), int(math.Round(ny)))
+			}
+			cur.x, cur.y = nx, ny
+		case '+', '-':
+			a := l.Angle
+			if len(m.params) > 0 {
+				a = m.params[0]
+			}
+			if m.sym == '-' {
+				a = -a
+			}
+			cur.heading += a
+		case '|':
+			cur.heading += 180
+		case '[':
+			stack = append(stack, cur)
+		case ']':
+			if len(stack) == 0 {
+				return fmt.Errorf("unbalanced ] in L-system")
+			}
+			cur = stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+		}
+	}
+	return nil
+}
+
+// compiledRule is a Rule parsed for rewriting.
+type compiledRule struct {
+	sym     rune
+	formals []string
+	cond    expr
+	succ    []succModule
+	weight  float64
+}
+
+type succModule struct {
+	sym    rune
+	params []expr
+}
+
+func (l *LSystem) expand() ([]module, error) {
+	axiom, err := parseModules(l.Axiom, nil)
+	if err != nil {
+		return nil, fmt.Errorf("axiom: %v", err)
+	}
+	mods := make([]module, len(axiom))
+	for j, s := range axiom {
+		mods[j], err = s.eval(nil)
+		if err != nil {
+			return nil, fmt.Errorf("axiom: %v", err)
+		}
+	}
+
+	rules := make(map[rune][]compiledRule)
+	for _, r := range l.Rules {
+		cr, err := compileRule(r)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %v", r.Pred, err)
+		}
+		rules[cr.sym] = append(rules[cr.sym], cr)
+	}
+
+	var matches []*compiledRule
+	for n := 0; n < l.Iterations; n++ {
+		next := make([]module, 0, len(mods))
+		for _, m := range mods {
+			matches = matches[:0]
+			total := 0.0
+			for j := range rules[m.sym] {
+				r := &rules[m.sym][j]
+				if len(r.formals) > 0 && len(r.formals) != len(m.params) {
+					continue
+				}
+				if r.cond != nil {
+					v, err := r.cond(m.params)
+					if err != nil {
+						return nil, err
+					}
+					if v == 0 {
+						continue
+					}
+				}
+				matches = append(matches, r)
+				total += r.weight
+			}
+			if len(matches) == 0 {
+				next = append(next, m)
+				continue
+			}
+
+			r := matches[0]
+			if len(matches) > 1 {
+				pick := l.random() * total
+				for _, c := range matches {
+					r = c
+					pick -= c.weight
+					if pick < 0 {
+						break
+					}
+				}
+			}
+			for _, s := range r.succ {
+				nm, err := s.eval(m.params)
+				if err != nil {
+					return nil, err
+				}
+				next = append(next, nm)
+			}
+			if len(next) > maxModules {
+				return nil, fmt.Errorf("L-system grew past %v symbols", maxModules)
+			}
+		}
+		mods = next
+	}
+	return mods, nil
+}
+
+func (l *LSystem) random() float64 {
+	if l.Rand != nil {
+		return l.Rand.Float64()
+	}
+	return rand.Float64()
+}
+
+func (s succModule) eval(args []float64) (module, error) {
+	m := module{sym: s.sym}
+	for _, e := range s.params {
+		v, err := e(args)
+		if err != nil {
+			return module{}, err
+		}
+		m.params = append(m.params, v)
+	}
+	return m, nil
+}
+
+func compileRule(r Rule) (compiledRule, error) {
+	pred := strings.TrimSpace(r.Pred)
+	if pred == "" {
+		return compiledRule{}, fmt.Errorf("empty predecessor")
+	}
+	cr := compiledRule{weight: r.Weight}
+	if cr.weight <= 0 {
+		cr.weight = 1
+	}
+	sym, n := utf8.DecodeRuneInString(pred)
+	cr.sym = sym
+	rest := strings.TrimSpace(pred[n:])
+	if rest != "" {
+		if !strings.HasPrefix(rest, "(") || !strings.HasSuffix(rest, ")") {
+			return compiledRule{}, fmt.Errorf("invalid predecessor")
+		}
+		for _, f := range strings.Split(rest[1:len(rest)-1], ",") {
+			f = strings.TrimSpace(f)
+			if !isIdent(f) {
+				return compiledRule{}, fmt.Errorf("invalid parameter %q", f)
+			}
+			cr.formals = append(cr.formals, f)
+		}
+	}
+
+	var err error
+	if strings.TrimSpace(r.Cond) != "" {
+		cr.cond, err = compileExpr(r.Cond, cr.formals)
+		if err != nil {
+			return compiledRule{}, fmt.Errorf("condition: %v", err)
+		}
+	}
+	cr.succ, err = parseModules(r.Succ, cr.formals)
+	if err != nil {
+		return compiledRule{}, fmt.Errorf("successor: %v", err)
+	}
+	return cr, nil
+}
+
+func isIdent(s string) bool {
+	if s == "" {
+		return false
+	}
+	for j, r := range s {
+		if !unicode.IsLetter(r) && r != '_' && (j == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseModules splits s into symbols, each optionally followed by a
+// parenthesised list of expressions of names.
+func parseModules(s string, names []string) ([]succModule, error) {
+	var out []succModule
+	rs := []rune(s)
+	for j := 0; j < len(rs); j++ {
+		r := rs[j]
+		if unicode.IsSpace(r) {
+			continue
+		}
+		if r == '(' || r == ')' || r == ',' {
+			return nil, fmt.Errorf("unexpected %q", r)
+		}
+		m := succModule{sym: r}
+		if j+1 < len(rs) && rs[j+1] == '(' {
+			depth, start := 0, j+2
+			k := j + 1
+			for ; k < len(rs); k++ {
+				switch c := rs[k]; {
+				case c == '(':
+					depth++
+					continue
+				case c == ')':
+					depth--
+					if depth > 0 {
+						continue
+					}
+				case c != ',' || depth != 1:
+					continue
+				}
+				// a top level comma or the closing parenthesis
+				e, err := compileExpr(string(rs[start:k]), names)
+				if err != nil {
+					return nil, err
+				}
+				m.params = append(m.params, e)
+				start = k + 1
+				if depth == 0 {
+					break
+				}
+			}
+			if k == len(rs) {
+				return nil, fmt.Errorf("missing )")
+			}
+			j = k
+		}
+		out = append(out, m)
+	}
+	return out, nil
+}
+
+// expr is a compiled expression of the parameters of a module. Booleans
+// are 1 and 0.
+type expr func(args []float64) (float64, error)
+
+func compileExpr(s string, names []string) (expr, error) {
+	p := &exprParser{src: s, names: names}
+	p.next()
+	e, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok != "" {
+		return nil, fmt.Errorf("unexpected %q in %q", p.tok, s)
+	}
+	return e, nil
+}
+
+type exprParser struct {
+	src   string
+	pos   int
+	tok   string
+	names []string
+}
+
+// next reads the next token into tok, which is empty at the end.
+func (p *exprParser) next() {
+	for p.pos < len(p.src) && p.src[p.pos] == ' ' {
+		p.pos++
+	}
+	if p.pos >= len(p.src) {
+		p.tok = ""
+		return
+	}
+	start := p.pos
+	c := p.src[p.pos]
+	switch {
+	case c >= '0' && c <= '9' || c == '.':
+		for p.pos < len(p.src) && (p.src[p.pos] >= '0' && p.src[p.pos] <= '9' || p.src[p.pos] == '.') {
+			p.pos++
+		}
+	case c == '_' || unicode.IsLetter(rune(c)):
+		for p.pos < len(p.src) && (p.src[p.pos] == '_' || unicode.IsLetter(rune(p.src[p.pos])) || p.src[p.pos] >= '0' && p.src[p.pos] <= '9') {
+			p.pos++
+		}
+	default:
+		p.pos++
+		if p.pos < len(p.src) {
+			switch two := p.src[start : p.pos+1]; two {
+			case "<=", ">=", "==", "!=", "&&", "||":
+				p.pos++
+			}
+		}
+	}
+	p.tok = p.src[start:p.pos]
+}
+
+func (p *exprParser) binary(ops []string, operand func() (expr, error), apply func(op string, a, b float64) float64) (expr, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := ""
+		for _, o := range ops {
+			if p.tok == o {
+				op = o
+			}
+		}
+		if op == "" {
+			return left, nil
+		}
+		p.next()
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(args []float64) (float64, error) {
+			a, err := l(args)
+			if err != nil {
+				return 0, err
+			}
+			b, err := right(args)
+			if err != nil {
+				return 0, err
+			}
+			return apply(op, a, b), nil
+		}
+	}
+}
+
+func truth(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (p *exprParser) or() (expr, error) {
+	return p.binary([]string{"||"}, p.and, func(_ string, a, b float64) float64 {
+		return truth(a != 0 || b != 0)
+	})
+}
+
+func (p *exprParser) and() (expr, error) {
+	return p.binary([]string{"&&"}, p.cmp, func(_ string, a, b float64) float64 {
+		return truth(a != 0 && b != 0)
+	})
+}
+
+func (p *exprParser) cmp() (expr, error) {
+	return p.binary([]string{"<", ">", "<=", ">=", "==", "!="}, p.sum, func(op string, a, b float64) float64 {
+		switch op {
+		case "<":
+			return truth(a < b)
+		case ">":
+			return truth(a > b)
+		case "<=":
+			return truth(a <= b)
+		case ">=":
+			return truth(a >= b)
+		case "==":
+			return truth(a == b)
+		}
+		return truth(a != b)
+	})
+}
+
+func (p *exprParser) sum() (expr, error) {
+	return p.binary([]string{"+", "-"}, p.product, func(op string, a, b float64) float64 {
+		if op == "+" {
+			return a + b
+		}
+		return a - b
+	})
+}
+
+func (p *exprParser) product() (expr, error) {
+	return p.binary([]string{"*", "/"}, p.unary, func(op string, a, b float64) float64 {
+		if op == "*" {
+			return a * b
+		}
+		return a / b
+	})
+}
+
+func (p *exprParser) unary() (expr, error) {
+	switch p.tok {
+	case "-", "!":
+		op := p.tok
+		p.next()
+		e, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return func(args []float64) (float64, error) {
+			v, err := e(args)
+			if op == "-" {
+				return -v, err
+			}
+			return truth(v == 0), err
+		}, nil
+	}
+	return p.power()
+}
+
+func (p *exprParser) power() (expr, error) {
+	base, err := p.atom()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok != "^" {
+		return base, nil
+	}
+	p.next()
+	exp, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	return func(args []float64) (float64, error) {
+		a, err := base(args)
+		if err != nil {
+			return 0, err
+		}
+		b, err := exp(args)
+		return math.Pow(a, b), err
+	}, nil
+}
+
+func (p *exprParser) atom() (expr, error) {
+	tok := p.tok
+	switch {
+	case tok == "":
+		return nil, fmt.Errorf("unexpected end of %q", p.src)
+	case tok == "(":
+		p.next()
+		e, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok != ")" {
+			return nil, fmt.Errorf("missing ) in %q", p.src)
+		}
+		p.next()
+		return e, nil
+	case tok[0] >= '0' && tok[0] <= '9' || tok[0] == '.':
+		v, err := strconv.ParseFloat(tok, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", tok)
+		}
+		p.next()
+		return func([]float64) (float64, error) { return v, nil }, nil
+	case isIdent(tok):
+		for j, n := range p.names {
+			if n == tok {
+				p.next()
+				return func(args []float64) (float64, error) {
+					if j >= len(args) {
+						return 0, fmt.Errorf("missing parameter %v", tok)
+					}
+					return args[j], nil
+				}, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown parameter %q", tok)
+	}
+	return nil, fmt.Errorf("unexpected %q in %q", tok, p.src)
+}
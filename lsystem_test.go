@@ -0,0 +1,94 @@
+package graphos
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestExpr(t *testing.T) {
+	tests := []struct {
+		src  string
+		want float64
+	}{
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"x / y - 1", 1},
+		{"2 ^ 3 ^ 2", 512},
+		{"-2 ^ 2", -4},
+		{"-x + .5", -5.5},
+		{"x > y", 1},
+		{"x <= y", 0},
+		{"x == 6 && y != 3", 0},
+		{"x < 1 || y >= 3", 1},
+		{"!(x == 0)", 1},
+		{"!x", 0},
+	}
+	for _, tt := range tests {
+		e, err := compileExpr(tt.src, []string{"x", "y"})
+		if err != nil {
+			t.Errorf("%q: %v", tt.src, err)
+			continue
+		}
+		got, err := e([]float64{6, 3})
+		if err != nil || got != tt.want {
+			t.Errorf("%q = %v, %v, want %v", tt.src, got, err, tt.want)
+		}
+	}
+
+	for _, src := range []string{"", "x +", "(x", "z", "1 $ 2", "x y"} {
+		_, err := compileExpr(src, []string{"x"})
+		if err == nil {
+			t.Errorf("%q did not fail", src)
+		}
+	}
+}
+
+func TestLSystemExpand(t *testing.T) {
+	koch := LSystem{Axiom: "F", Rules: []Rule{{Pred: "F", Succ: "F+F-F-F+F"}}, Iterations: 2}
+	s, err := koch.Expand()
+	if want := "F+F-F-F+F+F+F-F-F+F-F+F-F-F+F-F+F-F-F+F+F+F-F-F+F"; err != nil || s != want {
+		t.Errorf("koch: %q, %v, want %q", s, err, want)
+	}
+
+	// the parametric example of The Algorithmic Beauty of Plants, 1.6
+	abop := LSystem{Axiom: "B(2)A(4,4)", Rules: []Rule{
+		{Pred: "A(x,y)", Cond: "y <= 3", Succ: "A(x*2,x+y)"},
+		{Pred: "A(x,y)", Cond: "y > 3", Succ: "B(x)A(x/y,0)"},
+		{Pred: "B(x)", Cond: "x < 1", Succ: "C"},
+		{Pred: "B(x)", Cond: "x >= 1", Succ: "B(x-1)"},
+	}, Iterations: 4}
+	s, err = abop.Expand()
+	if want := "CB(1)A(8,7)"; err != nil || s != want {
+		t.Errorf("abop: %q, %v, want %q", s, err, want)
+	}
+
+	for _, bad := range []LSystem{
+		{Axiom: "A(", Iterations: 1},
+		{Axiom: "A", Rules: []Rule{{Pred: "A(x", Succ: "B"}}, Iterations: 1},
+		{Axiom: "A(1)", Rules: []Rule{{Pred: "A(x)", Succ: "A(y)"}}, Iterations: 1},
+		{Axiom: "A(1)", Rules: []Rule{{Pred: "A(x)", Cond: "x >", Succ: "A"}}, Iterations: 1},
+	} {
+		_, err := bad.Expand()
+		if err == nil {
+			t.Errorf("%+v did not fail", bad)
+		}
+	}
+}
+
+func TestLSystemStochastic(t *testing.T) {
+	plant := func(seed int64) string {
+		l := LSystem{Axiom: "X", Rules: []Rule{
+			{Pred: "X", Succ: "F[+X][-X]FX", Weight: 1},
+			{Pred: "X", Succ: "F[+X]F[-X]+X", Weight: 2},
+			{Pred: "F", Succ: "FF"},
+		}, Iterations: 4, Rand: rand.New(rand.NewSource(seed))}
+		s, err := l.Expand()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	if plant(1) != plant(1) {
+		t.Error("the same seed gave different plants")
+	}
+}
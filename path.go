@@ -0,0 +1,294 @@
+package graphos
+
+import (
+	"image"
+	"math"
+)
+
+const (
+	// flatness is the largest distance, in pixels, between a curve and
+	// the line segments that approximate it.
+	flatness = 0.25
+	// maxSubdivision bounds the recursion when flattening curves.
+	maxSubdivision = 16
+)
+
+// Path is a vector outline made of one or more contours, built with
+// MoveTo, LineTo, QuadTo, CubicTo, ArcTo and Close and drawn with
+// StrokePath or FillPath. Coordinates follow the rest of the package:
+// integer values are pixel centres. Curves are flattened as they are added.
+type Path struct {
+	contours [][]vec
+	closed   []bool
+	start    vec
+}
+
+func NewPath() *Path {
+	return &Path{}
+}
+
+func pathPoint(x, y float64) vec {
+	return vec{x + 0.5, y + 0.5}
+}
+
+// current returns the last point of the path, starting a contour at the
+// origin if there is none.
+func (p *Path) current() vec {
+	n := len(p.contours)
+	switch {
+	case n == 0:
+		p.MoveTo(0, 0)
+	case p.closed[n-1]:
+		p.contours = append(p.contours, []vec{p.start})
+		p.closed = append(p.closed, false)
+	}
+	c := p.contours[len(p.contours)-1]
+	return c[len(c)-1]
+}
+
+func (p *Path) add(v vec) {
+	p.current()
+	n := len(p.contours) - 1
+	p.contours[n] = append(p.contours[n], v)
+}
+
+// MoveTo starts a new contour at x, y.
+func (p *Path) MoveTo(x, y float64) {
+	v := pathPoint(x, y)
+	p.start = v
+	n := len(p.contours)
+	if n > 0 && !p.closed[n-1] && len(p.contours[n-1]) == 1 {
+		p.contours[n-1][0] = v
+		return
+	}
+	p.contours = append(p.contours, []vec{v})
+	p.closed = append(p.closed, false)
+}
+
+func (p *Path) LineTo(x, y float64) {
+	p.add(pathPoint(x, y))
+}
+
+// QuadTo adds a quadratic Bezier curve with control point cx, cy.
+func (p *Path) QuadTo(cx, cy, x, y float64) {
+	p0 := p.current()
+	flattenQuad(p0, pathPoint(cx, cy), pathPoint(x, y), 0, p.add)
+}
+
+// CubicTo adds a cubic Bezier curve with control points c1 and c2.
+func (p *Path) CubicTo(c1x, c1y, c2x, c2y, x, y float64) {
+	p0 := p.current()
+	flattenCubic(p0, pathPoint(c1x, c1y), pathPoint(c2x, c2y), pathPoint(x, y), 0, p.add)
+}
+
+// ArcTo adds a circular arc of the given radius tangent to the line from
+// the current point to x1, y1 and to the line from x1, y1 to x2, y2, joined
+// to the current point by a straight line, like arcTo in HTML canvas.
+func (p *Path) ArcTo(x1, y1, x2, y2, radius float64) {
+	p0 := p.current()
+	p1, p2 := pathPoint(x1, y1), pathPoint(x2, y2)
+	d1, d2 := p0.sub(p1), p2.sub(p1)
+	l1, l2 := d1.len(), d2.len()
+	if radius <= 0 || l1 == 0 || l2 == 0 || math.Abs(d1.cross(d2)) < 1e-9 {
+		p.add(p1)
+		return
+	}
+	u1, u2 := d1.mul(1/l1), d2.mul(1/l2)
+	theta := math.Acos(math.Max(-1, math.Min(1, u1.dot(u2))))
+	dist := radius / math.Tan(theta/2)
+	t1 := p1.add(u1.mul(dist))
+	t2 := p1.add(u2.mul(dist))
+	bis := u1.add(u2)
+	c := p1.add(bis.mul(radius / math.Sin(theta/2) / bis.len()))
+
+	p.add(t1)
+	a1 := math.Atan2(t1.y-c.y, t1.x-c.x)
+	a2 := math.Atan2(t2.y-c.y, t2.x-c.x)
+	sweep := a2 - a1
+	for sweep > math.Pi {
+		sweep -= 2 * math.Pi
+	}
+	for sweep < -math.Pi {
+		sweep += 2 * math.Pi
+	}
+	n := arcSegments(radius, sweep)
+	for j := 1; j <= n; j++ {
+		a := a1 + sweep*float64(j)/float64(n)
+		p.add(vec{c.x + radius*math.Cos(a), c.y + radius*math.Sin(a)})
+	}
+}
+
+// arcSegments returns how many chords approximate an arc within flatness.
+func arcSegments(radius, sweep float64) int {
+	step := math.Pi / 2
+	if radius > flatness {
+		step = 2 * math.Acos(1-flatness/radius)
+	}
+	n := int(math.Ceil(math.Abs(sweep) / step))
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// Close closes the current contour with a line back to its first point.
+// The next segment starts a new contour at the same point.
+func (p *Path) Close() {
+	n := len(p.contours)
+	if n == 0 || p.closed[n-1] {
+		return
+	}
+	p.closed[n-1] = true
+	p.start = p.contours[n-1][0]
+}
+
+func flattenQuad(p0, p1, p2 vec, depth int, add func(vec)) {
+	// distance of the control points from the chord, times its length
+	d := p2.sub(p0)
+	l := d.len()
+	flat := math.Abs(p1.sub(p0).cross(d)) <= flatness*l
+	if l == 0 {
+		flat = p1.sub(p0).len() <= flatness
+	}
+	if depth >= maxSubdivision || flat {
+		add(p2)
+		return
+	}
+	a := p0.add(p1).mul(0.5)
+	b := p1.add(p2).mul(0.5)
+	m := a.add(b).mul(0.5)
+	flattenQuad(p0, a, m, depth+1, add)
+	flattenQuad(m, b, p2, depth+1, add)
+}
+
+func flattenCubic(p0, p1, p2, p3 vec, depth int, add func(vec)) {
+	// distance of the control points from the chord, times its length
+	d := p3.sub(p0)
+	l := d.len()
+	d1 := math.Abs(p1.sub(p0).cross(d))
+	d2 := math.Abs(p2.sub(p0).cross(d))
+	flat := d1+d2 <= flatness*l
+	if l == 0 {
+		flat = p1.sub(p0).len()+p2.sub(p0).len() <= flatness
+	}
+	if depth >= maxSubdivision || flat {
+		add(p3)
+		return
+	}
+	a := p0.add(p1).mul(0.5)
+	b := p1.add(p2).mul(0.5)
+	c := p2.add(p3).mul(0.5)
+	ab := a.add(b).mul(0.5)
+	bc := b.add(c).mul(0.5)
+	m := ab.add(bc).mul(0.5)
+	flattenCubic(p0, a, ab, m, depth+1, add)
+	flattenCubic(m, bc, c, p3, depth+1, add)
+}
+
+// StrokePath draws the contours of path with CurrentColor and the current
+// line width, caps and joins.
+func (s *Surface) StrokePath(path *Path) {
+	defer s.once()()
+	for j, c := range path.contours {
+		if len(c) < 2 {
+			// a MoveTo with nothing after it
+			continue
+		}
+		if s.thick() {
+			s.strokeThick(c, path.closed[j])
+			continue
+		}
+		s.strokeThin(c, path.closed[j])
+	}
+}
+
+// FillPath fills the contours of path with CurrentColor, closing open ones.
+func (s *Surface) FillPath(path *Path, rule FillRule) {
+	s.fillPolygon(path.contours, rule, s.CurrentColor)
+}
+
+// strokeThin draws one pixel wide lines through pts, writing the pixel
+// shared by two segments once.
+func (s *Surface) strokeThin(pts []vec, closed bool) {
+	if len(pts) == 0 {
+		return
+	}
+	px := func(v vec) (int, int) {
+		return int(math.Floor(v.x)), int(math.Floor(v.y))
+	}
+	plot := func(x, y, k int) {
+		if s.dashOn(k) {
+			s.DrawPix(x, y, s.CurrentColor)
+		}
+	}
+	x0, y0 := px(pts[0])
+	plot(x0, y0, 0)
+	k := 0
+	n := len(pts)
+	if closed {
+		n++
+	}
+	for j := 1; j < n; j++ {
+		x1, y1 := px(pts[j%len(pts)])
+		first := true
+		bresenham(x0, y0, x1, y1, func(x, y int) {
+			if first {
+				first = false
+				return
+			}
+			k++
+			if closed && j == n-1 && x == x1 && y == y1 {
+				return
+			}
+			plot(x, y, k)
+		})
+		x0, y0 = x1, y1
+	}
+}
+
+// DrawQuadBezier draws a quadratic Bezier curve from x0, y0 to x1, y1 with
+// control point cx, cy.
+func (s *Surface) DrawQuadBezier(x0, y0, cx, cy, x1, y1 int) {
+	p := NewPath()
+	p.MoveTo(float64(x0), float64(y0))
+	p.QuadTo(float64(cx), float64(cy), float64(x1), float64(y1))
+	s.StrokePath(p)
+}
+
+// DrawCubicBezier draws a cubic Bezier curve from x0, y0 to x1, y1 with
+// control points c1 and c2.
+func (s *Surface) DrawCubicBezier(x0, y0, c1x, c1y, c2x, c2y, x1, y1 int) {
+	p := NewPath()
+	p.MoveTo(float64(x0), float64(y0))
+	p.CubicTo(float64(c1x), float64(c1y), float64(c2x), float64(c2y), float64(x1), float64(y1))
+	s.StrokePath(p)
+}
+
+// DrawCatmullRom draws a uniform Catmull-Rom spline passing through every
+// point of pts.
+func (s *Surface) DrawCatmullRom(pts []image.Point) {
+	s.StrokePath(CatmullRomPath(pts))
+}
+
+// CatmullRomPath returns a path with a uniform Catmull-Rom spline through
+// pts, converted to cubic Bezier segments.
+func CatmullRomPath(pts []image.Point) *Path {
+	p := NewPath()
+	if len(pts) == 0 {
+		return p
+	}
+	v := make([]vec, len(pts))
+	for j, pt := range pts {
+		v[j] = vec{float64(pt.X), float64(pt.Y)}
+	}
+	p.MoveTo(v[0].x, v[0].y)
+	for j := 0; j+1 < len(v); j++ {
+		p0 := v[max(j-1, 0)]
+		p1, p2 := v[j], v[j+1]
+		p3 := v[min(j+2, len(v)-1)]
+		c1 := p1.add(p2.sub(p0).mul(1.0 / 6))
+		c2 := p2.sub(p3.sub(p1).mul(1.0 / 6))
+		p.CubicTo(c1.x, c1.y, c2.x, c2.y, p2.x, p2.y)
+	}
+	return p
+}
@@ -0,0 +1,26 @@
+package graphos
+
+import (
+	"image"
+	"testing"
+)
+
+func TestStrokePathLoneMoveTo(t *testing.T) {
+	for _, width := range []float64{1, 4} {
+		for _, lineCap := range []LineCap{CapButt, CapRound, CapSquare} {
+			s := NewSurface(40, 40)
+			s.CurrentColor = white
+			s.LineWidth, s.LineCap = width, lineCap
+			p := NewPath()
+			p.MoveTo(1, 1)
+			p.LineTo(5, 1)
+			p.MoveTo(20, 20)
+			s.StrokePath(p)
+			for pt := range lit(s) {
+				if pt.In(image.Rect(15, 15, 25, 25)) {
+					t.Fatalf("width %v cap %v: pixel %v drawn for a lone MoveTo", width, lineCap, pt)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package graphos
+
+import (
+	"image"
+	"math"
+	"sort"
+)
+
+// FillRule decides which parts of a self-intersecting or nested polygon
+// are inside.
+type FillRule int
+
+const (
+	// FillEvenOdd fills points crossed by an odd number of edges on the way
+	// out of the polygon.
+	FillEvenOdd FillRule = iota
+	// FillNonZero fills points around which the outline winds at least once.
+	FillNonZero
+)
+
+// DrawPolygon draws the closed outline through pts with the current line
+// width, caps and joins.
+func (s *Surface) DrawPolygon(pts []image.Point) {
+	s.drawPolyline(pts, true)
+}
+
+// DrawFilledPolygon fills the polygon pts, closing it automatically.
+// Vertices are pixel centres and a pixel is filled when its centre is
+// inside; centres exactly on the boundary are filled on top and left
+// edges only, so polygons sharing an edge never overlap.
+func (s *Surface) DrawFilledPolygon(pts []image.Point, rule FillRule) {
+	if len(pts) < 3 {
+		return
+	}
+	v := make([]vec, len(pts))
+	for j, pt := range pts {
+		v[j] = center(pt.X, pt.Y)
+	}
+	s.fillPolygon([][]vec{v}, rule, s.CurrentColor)
+}
+
+type polyEdge struct {
+	a, b vec
+	dir  int
+}
+
+type crossing struct {
+	x   float64
+	dir int
+}
+
+// fillPolygon scan converts the closed contours with the given fill rule.
+func (s *Surface) fillPolygon(contours [][]vec, rule FillRule, c Color) {
+	var edges []polyEdge
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	for _, pts := range contours {
+		for j := range pts {
+			a, b := pts[j], pts[(j+1)%len(pts)]
+			if a.y == b.y {
+				continue
+			}
+			dir := 1
+			if a.y > b.y {
+				a, b = b, a
+				dir = -1
+			}
+			edges = append(edges, polyEdge{a, b, dir})
+			minY = math.Min(minY, a.y)
+			maxY = math.Max(maxY, b.y)
+		}
+	}
+	if len(edges) == 0 {
+		return
+	}
+	sort.Slice(edges, func(i, j int) bool { return edges[i].a.y < edges[j].a.y })
+
+	y0 := int(math.Ceil(minY - 0.5))
+	y1 := int(math.Ceil(maxY-0.5)) - 1
+	if y0 < s.clip.Min.Y {
+		y0 = s.clip.Min.Y
+	}
+	if y1 >= s.clip.Max.Y {
+		y1 = s.clip.Max.Y - 1
+	}
+
+	var xs []crossing
+	for y := y0; y <= y1; y++ {
+		yc := float64(y) + 0.5
+		xs = xs[:0]
+		for _, e := range edges {
+			if e.a.y > yc {
+				break
+			}
+			if e.b.y <= yc {
+				continue
+			}
+			xs = append(xs, crossing{edgeX(e.a, e.b, yc), e.dir})
+		}
+		sort.Slice(xs, func(i, j int) bool { return xs[i].x < xs[j].x })
+
+		winding := 0
+		for j := 0; j+1 < len(xs); j++ {
+			if rule == FillEvenOdd {
+				winding ^= 1
+			} else {
+				winding += xs[j].dir
+			}
+			if winding == 0 {
+				continue
+			}
+			s.span(xs[j].x, xs[j+1].x, y, c)
+		}
+	}
+}
+
+// edgeX returns where the edge from a to b, with a.y < b.y, crosses the
+// row at yc.
+func edgeX(a, b vec, yc float64) float64 {
+	return a.x + (yc-a.y)*(b.x-a.x)/(b.y-a.y)
+}
+
+// span fills the pixels of row y whose centres lie in [xl, xr).
+func (s *Surface) span(xl, xr float64, y int, c Color) {
+	x0 := int(math.Ceil(xl - 0.5))
+	x1 := int(math.Ceil(xr-0.5)) - 1
+	if x0 <= x1 {
+		s.fillSpan(x0, x1, y, c)
+	}
+}
+
+// DrawFilledTriangle fills a triangle with the same pixel rules as
+// DrawFilledPolygon, walking its edges directly.
+func (s *Surface) DrawFilledTriangle(x0, y0, x1, y1, x2, y2 int) {
+	p := [3]vec{center(x0, y0), center(x1, y1), center(x2, y2)}
+	if p[1].y < p[0].y {
+		p[0], p[1] = p[1], p[0]
+	}
+	if p[2].y < p[1].y {
+		p[1], p[2] = p[2], p[1]
+	}
+	if p[1].y < p[0].y {
+		p[0], p[1] = p[1], p[0]
+	}
+	a, b, c := p[0], p[1], p[2]
+	if a.y == c.y {
+		return
+	}
+
+	ystart := int(math.Ceil(a.y - 0.5))
+	yend := int(math.Ceil(c.y-0.5)) - 1
+	if ystart < s.clip.Min.Y {
+		ystart = s.clip.Min.Y
+	}
+	if yend >= s.clip.Max.Y {
+		yend = s.clip.Max.Y - 1
+	}
+
+	for y := ystart; y <= yend; y++ {
+		yc := float64(y) + 0.5
+		xl := edgeX(a, c, yc)
+		var xr float64
+		if yc < b.y {
+			xr = edgeX(a, b, yc)
+		} else {
+			xr = edgeX(b, c, yc)
+		}
+		if xl > xr {
+			xl, xr = xr, xl
+		}
+		s.span(xl, xr, y, s.CurrentColor)
+	}
+}
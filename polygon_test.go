@@ -0,0 +1,43 @@
+package graphos
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+// star returns a pentagram around cx, cy, drawn so that its centre is
+// wound twice.
+func star(cx, cy, r float64) []image.Point {
+	var pts []image.Point
+	for j := 0; j < 5; j++ {
+		a := math.Pi/2 + float64(j*2)*2*math.Pi/5
+		pts = append(pts, image.Pt(int(math.Round(cx+r*math.Cos(a))), int(math.Round(cy-r*math.Sin(a)))))
+	}
+	return pts
+}
+
+func TestFillRules(t *testing.T) {
+	tests := []struct {
+		rule          FillRule
+		centre, spike bool
+	}{
+		{FillEvenOdd, false, true},
+		{FillNonZero, true, true},
+	}
+	for _, tt := range tests {
+		s := NewSurface(100, 100)
+		s.CurrentColor = white
+		s.DrawFilledPolygon(star(50, 50, 45), tt.rule)
+		px := lit(s)
+		if px[image.Pt(50, 50)] != tt.centre {
+			t.Errorf("rule %v: centre filled %v, want %v", tt.rule, !tt.centre, tt.centre)
+		}
+		if px[image.Pt(50, 12)] != tt.spike {
+			t.Errorf("rule %v: spike filled %v, want %v", tt.rule, !tt.spike, tt.spike)
+		}
+		if px[image.Pt(2, 2)] {
+			t.Errorf("rule %v: outside pixel filled", tt.rule)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package graphos
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"io"
+	"os"
+	"time"
+)
+
+// Recorder captures presented frames and writes them as an animated GIF.
+// Assign it to Instance.Recorder and call Start; every frame presented while
+// recording is captured. Colors16 is always part of the GIF palette, so
+// frames drawn with it are stored losslessly; other colours are mapped to
+// the nearest web-safe colour. The GIF has the size of the first frame;
+// frames captured after the screen is resized are cropped or padded with
+// black to it.
+type Recorder struct {
+	// FrameSkip is the number of presented frames dropped between two
+	// captured frames.
+	FrameSkip int
+	// MaxDuration stops the recording automatically once exceeded. Zero
+	// means no limit.
+	MaxDuration time.Duration
+
+	recording bool
+	started   bool
+	start     time.Duration
+	last      time.Duration
+	skipped   int
+	anim      gif.GIF
+	index     map[uint32]uint8
+}
+
+var recorderPalette = func() color.Palette {
+	p := make(color.Palette, 0, 256)
+	for _, c := range Colors16 {
+		p = append(p, color.RGBA{c[0], c[1], c[2], c[3]})
+	}
+	for _, c := range palette.WebSafe {
+		if p[p.Index(c)] == c {
+			continue
+		}
+		p = append(p, c)
+	}
+	return p
+}()
+
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Start discards any previous capture and starts recording.
+func (r *Recorder) Start() {
+	r.anim = gif.GIF{}
+	r.index = make(map[uint32]uint8)
+	r.skipped = 0
+	r.started = false
+	r.recording = true
+}
+
+// Stop ends the recording. The captured frames are kept until the next
+// Start; the last one is shown as long as the one before it.
+func (r *Recorder) Stop() {
+	r.recording = false
+}
+
+func (r *Recorder) Recording() bool {
+	return r.recording
+}
+
+// Len returns the number of captured frames.
+func (r *Recorder) Len() int {
+	return len(r.anim.Image)
+}
+
+// capture adds img as a frame presented at the instance time now.
+func (r *Recorder) capture(img *image.RGBA, now time.Duration) {
+	if !r.recording {
+		return
+	}
+	if !r.started {
+		r.started = true
+		r.start = now
+		r.last = now
+	}
+	if r.MaxDuration > 0 && now-r.start > r.MaxDuration {
+		r.Stop()
+		return
+	}
+	if r.skipped < r.FrameSkip && len(r.anim.Image) > 0 {
+		r.skipped++
+		return
+	}
+	r.skipped = 0
+
+	// GIF delays are in hundredths of a second; most viewers treat less
+	// than 2 as a default of 10.
+	d := int((now - r.last) / (10 * time.Millisecond))
+	if d < 2 {
+		d = 2
+	}
+	n := len(r.anim.Delay)
+	if n > 0 {
+		r.anim.Delay[n-1] = d
+	}
+	r.last = now
+
+	if len(r.anim.Image) == 0 {
+		r.anim.Config = image.Config{
+			ColorModel: recorderPalette,
+			Width:      img.Rect.Dx(),
+			Height:     img.Rect.Dy(),
+		}
+	}
+	p := image.NewPaletted(image.Rect(0, 0, r.anim.Config.Width, r.anim.Config.Height), recorderPalette)
+	b := img.Rect.Intersect(p.Rect)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		src := img.Pix[img.PixOffset(b.Min.X, y):]
+		dst := p.Pix[p.PixOffset(b.Min.X, y):]
+		for x := 0; x < b.Dx(); x++ {
+			c := src[x*4 : x*4+4]
+			key := uint32(c[0])<<24 | uint32(c[1])<<16 | uint32(c[2])<<8 | uint32(c[3])
+			idx, ok := r.index[key]
+			if !ok {
+				idx = uint8(recorderPalette.Index(color.RGBA{c[0], c[1], c[2], c[3]}))
+				r.index[key] = idx
+			}
+			dst[x] = idx
+		}
+	}
+	r.anim.Image = append(r.anim.Image, p)
+	r.anim.Delay = append(r.anim.Delay, d)
+}
+
+// WriteGIF encodes the captured frames as an animated GIF that loops forever.
+func (r *Recorder) WriteGIF(w io.Writer) error {
+	if len(r.anim.Image) == 0 {
+		return errors.New("graphos: no frames recorded")
+	}
+	return gif.EncodeAll(w, &r.anim)
+}
+
+func (r *Recorder) SaveGIF(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = r.WriteGIF(f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
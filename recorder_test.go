@@ -0,0 +1,51 @@
+package graphos
+
+import (
+	"bytes"
+	"image/gif"
+	"testing"
+)
+
+func TestRecorderResize(t *testing.T) {
+	i := New()
+	i.ScreenHandler = func(i *Instance) error {
+		i.DrawFilledBox(0, 0, 9, 9, Colors16[4])
+		i.UpdateScreen = true
+		return nil
+	}
+	i.Init()
+	i.Recorder = NewRecorder()
+	i.Recorder.Start()
+	for n := 0; n < 5; n++ {
+		err := i.Step()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	w, h := i.Width, i.Height
+	for _, size := range [][2]int{{80, 80}, {w + 20, h + 10}} {
+		i.Resize(size[0], size[1])
+		err := i.Step()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	err := i.Recorder.WriteGIF(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Image) != 7 || g.Config.Width != w || g.Config.Height != h {
+		t.Fatalf("got %v frames of %vx%v, want 7 of %vx%v", len(g.Image), g.Config.Width, g.Config.Height, w, h)
+	}
+	for n, f := range g.Image {
+		if f.Rect.Dx() != w || f.Rect.Dy() != h {
+			t.Errorf("frame %v is %v", n, f.Rect)
+		}
+	}
+}
@@ -4,10 +4,16 @@ import (
 	"image"
 	"log"
 	"strings"
+	"sync"
+	"time"
 
 	"crg.eti.br/go/graphos/fonts"
-	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+const (
+	cursorBlinkPeriod = 1250 * time.Millisecond
+	keyRepeatDelay    = 400 * time.Millisecond
+	maxCatchUpSteps   = 5
 )
 
 const (
@@ -18,19 +24,23 @@ const (
 )
 
 type Instance struct {
+	Surface
 	UpdateScreen       bool
 	Running            bool
 	textMemory         [totalTextSize]byte
 	textMemoryAtribute [totalTextSize]byte
 	Height             int
 	Width              int
-	CurrentColor       Color
 	UTime              uint64
-	img                *image.RGBA
+	Elapsed            time.Duration
+	Delta              time.Duration
+	Alpha              float64
+	Config             Config
+	RenderHandler      func(*Instance) error
+	Frames             uint64
 	ScreenHandler      func(*Instance) error
 	Title              string
 	cursor             int
-	cursorBlinkTimer   int
 	cursorSetBlink     bool
 	cursorLine         int
 	cursorColumn       int
@@ -42,13 +52,37 @@ type Instance struct {
 		Bitmap []byte
 	}
 	lastKey struct {
-		Time uint64
+		Time time.Duration
 		Char byte
 	}
-	noKey bool
+	noKey       bool
+	Video       VideoBackend
+	InputDevice InputBackend
+	Audio       AudioBackend
+	Recorder    *Recorder
+	Screenshot  struct {
+		Enabled bool
+		Key     Key
+		Dir     string
+		Text    bool
+	}
+	textMode    bool
+	lastUpdate  time.Time
+	accumulator time.Duration
+	mu          sync.Mutex
+	back        *Surface
+	backOnce    sync.Once
+	turtles     []*Turtle
+	// defaultBackend is the backend New installed, replaced by
+	// HeadlessBackend for headless runs
+	defaultBackend backend
 }
 
 func New() *Instance {
+	return NewWithConfig(DefaultConfig())
+}
+
+func NewWithConfig(cfg Config) *Instance {
 	var i *Instance
 	i = &Instance{}
 	i.Width = columns * 9
@@ -60,6 +94,13 @@ func New() *Instance {
 	i.Title = "term"
 	i.CurrentColor = Colors16[0x0F]
 	i.cursorSetBlink = true
+	i.Screenshot.Key = KeyF12
+	i.Config = cfg
+	b := newBackend()
+	i.Video = b
+	i.InputDevice = b
+	i.Audio = b
+	i.defaultBackend = b
 	return i
 }
 
@@ -94,103 +135,72 @@ func MergeColorCode(b, f byte) byte {
 	return f&0xff | b<<4
 }
 
-func (i *Instance) Run() {
-
+// Init loads the font and allocates the framebuffer. Run calls it before
+// opening the window; headless callers call it once before Step.
+func (i *Instance) Init() {
 	i.Font.Bitmap = fonts.Bitmap
 	i.Font.Height = 16
 	i.Font.Width = 9
 	i.img = image.NewRGBA(image.Rect(0, 0, i.Width, i.Height))
+	i.ResetClip()
 	i.Clear()
 	i.clearVideoTextMode()
-
-	ebiten.SetWindowTitle(i.Title)
-	ebiten.SetWindowSize(i.Width, i.Height)
-	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
-	ebiten.SetWindowDecorated(true)
-	ebiten.SetWindowFloating(false)
-	//ebiten.SetWindowPosition(0, 0)
-	ebiten.SetTPS(24)
-	ebiten.SetScreenClearedEveryFrame(false)
-	ebiten.SetVsyncEnabled(true)
-	ebiten.SetCursorMode(ebiten.CursorModeVisible)
-
-	i.Running = true
-
-	err := ebiten.RunGame(i)
-	if err != nil {
-		log.Fatal(err)
-	}
 }
 
-func (i *Instance) DrawPix(x, y int, color Color) {
-	pos := i.img.Stride*y + 4*x
-
-	copy(i.img.Pix[pos:pos+4], color[:])
+// Resize reallocates the framebuffer, keeping the part of the old content
+// that still fits, and resets the clip rectangle.
+func (i *Instance) Resize(width, height int) {
+	i.Width, i.Height = width, height
+	i.img = resizeRGBA(i.img, image.Rect(0, 0, width, height))
+	i.ResetClip()
+	i.damage(i.img.Rect)
+	i.UpdateScreen = true
 }
 
-func (i *Instance) DrawChar(index, fgColor, bgColor byte, x, y int) {
-	var a uint
-	var b uint
-	var lColor Color
-	for b = 0; b < 16; b++ {
-		for a = 0; a < 9; a++ {
-			x1 := int(a) + x
-			y1 := int(b) + y
-			if a == 8 {
-				c := Colors16[bgColor]
-				if index >= 192 && index <= 223 {
-					c = lColor
-				}
-				i.DrawPix(x1, y1, c)
-				continue
-			}
-			idx := uint(index)*16 + b
-			if fonts.Bitmap[idx]&(0x80>>a) != 0 {
-				lColor = Colors16[fgColor]
-				i.DrawPix(x1, y1, lColor)
-				continue
-			}
-			lColor = Colors16[bgColor]
-			i.DrawPix(x1, y1, lColor)
-		}
+// resizeRGBA returns a new image with bounds r holding the part of old, if
+// any, that fits.
+func resizeRGBA(old *image.RGBA, r image.Rectangle) *image.RGBA {
+	img := image.NewRGBA(r)
+	if old == nil {
+		return img
 	}
-}
-
-func (i *Instance) DrawString(s string, fgColor, bgColor byte, x, y int) {
-	for idx := 0; idx < len(s); idx++ {
-		i.DrawChar(s[idx], fgColor, bgColor, x, y)
-		x += 9
+	o := old.Rect.Intersect(r)
+	for y := o.Min.Y; y < o.Max.Y; y++ {
+		copy(img.Pix[img.PixOffset(o.Min.X, y):], old.Pix[old.PixOffset(o.Min.X, y):old.PixOffset(o.Max.X, y)])
 	}
+	return img
 }
 
-func (i *Instance) Clear() {
-	color := i.CurrentColor
-	pix := i.img.Pix
-	lenPix := len(pix)
-
-	copy(pix, color[:])
+// CursorPosition returns the mouse position read by the last call to Input,
+// in framebuffer coordinates.
+func (i *Instance) CursorPosition() (int, int) {
+	return i.cpx, i.cpy
+}
 
-	for j := 4; j < lenPix; j *= 2 {
-		copy(pix[j:], pix[:j])
-	}
+// Run opens the output through the video backend and runs the game loop
+// until the window is closed, Running is set to false or a handler
+// returns an error, which is then returned.
+func (i *Instance) Run() error {
+	i.Init()
+	i.setRunning(true)
+	err := i.Video.Run(i)
+	i.setRunning(false)
+	return err
 }
 
 func (i *Instance) DrawCursor(index, fgColor, bgColor byte, x, y int) {
 	if i.cursorSetBlink {
-		if i.cursorBlinkTimer < 15 {
+		if i.Elapsed%cursorBlinkPeriod < cursorBlinkPeriod/2 {
 			fgColor, bgColor = bgColor, fgColor
 		}
 		i.DrawChar(index, fgColor, bgColor, x, y)
-		i.cursorBlinkTimer++
-		if i.cursorBlinkTimer > 30 {
-			i.cursorBlinkTimer = 0
-		}
 		return
 	}
 	i.DrawChar(index, bgColor, fgColor, x, y)
 }
 
 func (i *Instance) DrawVideoTextMode() {
+	i.textMode = true
 	idx := 0
 	for r := 0; r < rows; r++ {
 		for c := 0; c < columns; c++ {
@@ -275,11 +285,11 @@ func (i *Instance) Println(msg string) {
 }
 
 func (i *Instance) keyTreatment(c byte, f func(c byte)) {
-	if i.noKey || i.lastKey.Char != c || i.lastKey.Time+10 < i.UTime {
+	if i.noKey || i.lastKey.Char != c || i.lastKey.Time+keyRepeatDelay < i.Elapsed {
 		f(c)
 		i.noKey = false
 		i.lastKey.Char = c
-		i.lastKey.Time = i.UTime
+		i.lastKey.Time = i.Elapsed
 	}
 }
 
@@ -297,28 +307,30 @@ func (i *Instance) eval(cmd string) {
 	log.Println("eval:", cmd)
 }
 
-func (i *Instance) InputPressed(key ebiten.Key, f func(*Instance)) {
-	if inpututil.IsKeyJustPressed(key) {
+func (i *Instance) InputPressed(key Key, f func(*Instance)) {
+	if i.InputDevice.IsKeyJustPressed(key) {
 		f(i)
 	}
 }
 
-func (i *Instance) InputReleased(key ebiten.Key, f func(*Instance)) {
-	if inpututil.IsKeyJustReleased(key) {
+func (i *Instance) InputReleased(key Key, f func(*Instance)) {
+	if i.InputDevice.IsKeyJustReleased(key) {
 		f(i)
 	}
 }
 
 func (i *Instance) InputChars() []rune {
 	runes := make([]rune, 0, 16)
-	return ebiten.AppendInputChars(runes)
+	return i.InputDevice.AppendInputChars(runes)
 }
 
 func (i *Instance) Input() {
+	in := i.InputDevice
+
 	for c := 'A'; c <= 'Z'; c++ {
-		if ebiten.IsKeyPressed(ebiten.Key(c) - 'A' + ebiten.KeyA) {
+		if in.IsKeyPressed(KeyA + Key(c-'A')) {
 			i.keyTreatment(byte(c), func(c byte) {
-				if ebiten.IsKeyPressed(ebiten.KeyShift) {
+				if in.IsKeyPressed(KeyShift) {
 					i.PutChar(c)
 					return
 				}
@@ -329,7 +341,7 @@ func (i *Instance) Input() {
 	}
 
 	for c := '0'; c <= '9'; c++ {
-		if ebiten.IsKeyPressed(ebiten.Key(c) - '0' + ebiten.Key0) {
+		if in.IsKeyPressed(Key0 + Key(c-'0')) {
 			i.keyTreatment(byte(c), func(c byte) {
 				i.PutChar(c)
 			})
@@ -337,21 +349,21 @@ func (i *Instance) Input() {
 		}
 	}
 
-	if ebiten.IsKeyPressed(ebiten.KeySpace) {
+	if in.IsKeyPressed(KeySpace) {
 		i.keyTreatment(byte(' '), func(c byte) {
 			i.PutChar(c)
 		})
 		return
 	}
 
-	if ebiten.IsKeyPressed(ebiten.KeyComma) {
+	if in.IsKeyPressed(KeyComma) {
 		i.keyTreatment(byte(','), func(c byte) {
 			i.PutChar(c)
 		})
 		return
 	}
 
-	if ebiten.IsKeyPressed(ebiten.KeyEnter) {
+	if in.IsKeyPressed(KeyEnter) {
 		i.keyTreatment(0, func(c byte) {
 			i.eval(i.getLine())
 			i.cursor += columnsWord
@@ -363,7 +375,7 @@ func (i *Instance) Input() {
 		return
 	}
 
-	if ebiten.IsKeyPressed(ebiten.KeyBackspace) {
+	if in.IsKeyPressed(KeyBackspace) {
 		i.keyTreatment(0, func(c byte) {
 			i.cursor--
 			line := i.cursor / columnsWord
@@ -393,9 +405,9 @@ func (i *Instance) Input() {
 	   KeyGraveAccent: `
 	*/
 
-	shift := ebiten.IsKeyPressed(ebiten.KeyShift)
+	shift := in.IsKeyPressed(KeyShift)
 
-	if ebiten.IsKeyPressed(ebiten.KeyEqual) {
+	if in.IsKeyPressed(KeyEqual) {
 		if shift {
 			i.keyTreatment('+', func(c byte) {
 				i.PutChar(c)
@@ -410,28 +422,28 @@ func (i *Instance) Input() {
 		return
 	}
 
-	if ebiten.IsKeyPressed(ebiten.KeyUp) {
+	if in.IsKeyPressed(KeyUp) {
 		i.keyTreatment(0, func(c byte) {
 			i.cursor -= columnsWord
 			i.correctVideoCursor()
 		})
 		return
 	}
-	if ebiten.IsKeyPressed(ebiten.KeyDown) {
+	if in.IsKeyPressed(KeyDown) {
 		i.keyTreatment(0, func(c byte) {
 			i.cursor += columnsWord
 			i.correctVideoCursor()
 		})
 		return
 	}
-	if ebiten.IsKeyPressed(ebiten.KeyLeft) {
+	if in.IsKeyPressed(KeyLeft) {
 		i.keyTreatment(0, func(c byte) {
 			i.cursor--
 			i.correctVideoCursor()
 		})
 		return
 	}
-	if ebiten.IsKeyPressed(ebiten.KeyRight) {
+	if in.IsKeyPressed(KeyRight) {
 		i.keyTreatment(0, func(c byte) {
 			i.cursor++
 			i.correctVideoCursor()
@@ -440,19 +452,19 @@ func (i *Instance) Input() {
 	}
 
 	// When the "left mouse button" is pressed...
-	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+	if in.IsMouseButtonPressed(MouseButtonLeft) {
 		//ebitenutil.DebugPrint(screen, "You're pressing the 'LEFT' mouse button.")
 	}
 	// When the "right mouse button" is pressed...
-	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonRight) {
+	if in.IsMouseButtonPressed(MouseButtonRight) {
 		//ebitenutil.DebugPrint(screen, "\nYou're pressing the 'RIGHT' mouse button.")
 	}
 	// When the "middle mouse button" is pressed...
-	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonMiddle) {
+	if in.IsMouseButtonPressed(MouseButtonMiddle) {
 		//ebitenutil.DebugPrint(screen, "\n\nYou're pressing the 'MIDDLE' mouse button.")
 	}
 
-	i.cpx, i.cpy = ebiten.CursorPosition()
+	i.cpx, i.cpy = in.CursorPosition()
 	//fmt.Printf("X: %d, Y: %d\n", i.cpx, i.cpy)
 
 	// Display the information with "X: xx, Y: xx" format
@@ -462,24 +474,93 @@ func (i *Instance) Input() {
 
 }
 
-func (i *Instance) Draw(screen *ebiten.Image) {
-	if i.UpdateScreen {
-		screen.WritePixels(i.img.Pix)
-		i.UpdateScreen = false
+// Present consumes a pending frame and returns the region of the
+// framebuffer that changed since the previous one. Video backends call it
+// once per drawn frame and upload only that region; an empty rectangle
+// means there is nothing to show. The frame lock must be held while
+// presenting and reading the framebuffer.
+func (i *Instance) Present() image.Rectangle {
+	if !i.UpdateScreen {
+		return image.Rectangle{}
+	}
+	i.UpdateScreen = false
+	i.Frames++
+	if i.Recorder != nil {
+		i.Recorder.capture(i.img, i.Elapsed)
+	}
+	r := i.dirty.Intersect(i.img.Rect)
+	i.dirty = image.Rectangle{}
+	return r
+}
+
+// Update runs one tick of the game loop, measuring the wall-clock time
+// since the previous call. ScreenHandler is called with the frame lock held.
+func (i *Instance) Update() error {
+	now := time.Now()
+	dt := i.TickDuration()
+	if !i.lastUpdate.IsZero() {
+		dt = now.Sub(i.lastUpdate)
 	}
+	i.lastUpdate = now
+	return i.advance(dt)
 }
 
-func (i *Instance) Layout(outsideWidth, outsideHeight int) (int, int) {
-	return i.Width, i.Height
+// advance moves the clock forward by dt and runs the handlers.
+func (i *Instance) advance(dt time.Duration) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.Config.FixedTimestep {
+		step := i.TickDuration()
+		i.accumulator += dt
+		if i.accumulator > maxCatchUpSteps*step {
+			i.accumulator = maxCatchUpSteps * step
+		}
+		for i.accumulator >= step {
+			i.accumulator -= step
+			i.Delta = step
+			i.Elapsed += step
+			err := i.tick()
+			if err != nil {
+				return err
+			}
+		}
+		i.Alpha = float64(i.accumulator) / float64(step)
+		if i.RenderHandler != nil {
+			err := i.RenderHandler(i)
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		i.Delta = dt
+		i.Elapsed += dt
+		err := i.tick()
+		if err != nil {
+			return err
+		}
+	}
+
+	if i.Screenshot.Enabled && i.InputDevice.IsKeyJustPressed(i.Screenshot.Key) {
+		i.screenshot()
+	}
+	return nil
 }
 
-func (i *Instance) Update() error {
+func (i *Instance) tick() error {
+	for _, t := range i.turtles {
+		t.hide()
+	}
 	if i.ScreenHandler != nil {
 		err := i.ScreenHandler(i)
 		if err != nil {
 			return err
 		}
 	}
+	for _, t := range i.turtles {
+		t.step(t.Speed)
+		t.show()
+	}
 
 	i.UTime++
 	return nil
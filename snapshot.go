@@ -0,0 +1,79 @@
+package graphos
+
+import (
+	"image"
+	"image/png"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Snapshot returns a copy of the framebuffer that is safe to keep after
+// the instance draws again.
+func (i *Instance) Snapshot() image.Image {
+	img := image.NewRGBA(i.img.Rect)
+	copy(img.Pix, i.img.Pix)
+	return img
+}
+
+func (i *Instance) SavePNG(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = png.Encode(f, i.Snapshot())
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// TextSnapshot returns the text buffer as plain text, one line per row with
+// trailing blanks removed. Characters outside printable ASCII are shown as '.'.
+func (i *Instance) TextSnapshot() string {
+	var sb strings.Builder
+	line := make([]byte, columnsWord)
+	for r := 0; r < rows; r++ {
+		for c := 0; c < columns; c++ {
+			ch := i.textMemory[r*columnsWord+c]
+			switch {
+			case ch == 0:
+				ch = ' '
+			case ch < 32 || ch > 126:
+				ch = '.'
+			}
+			line[c] = ch
+		}
+		sb.WriteString(strings.TrimRight(string(line), " "))
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+func (i *Instance) SaveText(path string) error {
+	return os.WriteFile(path, []byte(i.TextSnapshot()), 0644)
+}
+
+// screenshot writes a timestamped PNG to Screenshot.Dir and, when the text
+// mode is in use and Screenshot.Text is set, the text buffer next to it.
+func (i *Instance) screenshot() {
+	name := "graphos-" + time.Now().Format("20060102-150405.000")
+	base := filepath.Join(i.Screenshot.Dir, name)
+
+	err := i.SavePNG(base + ".png")
+	if err != nil {
+		log.Printf("screenshot: %v", err)
+		return
+	}
+
+	if !i.Screenshot.Text || !i.textMode {
+		return
+	}
+	err = i.SaveText(base + ".txt")
+	if err != nil {
+		log.Printf("screenshot: %v", err)
+	}
+}
@@ -0,0 +1,233 @@
+package graphos
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// Sprite is a bitmap for DrawSprite. Its pixels are straight alpha RGBA
+// colours in Pix or, when Palette is set, indexes into Palette in Indexes,
+// row by row. Pixels equal to Key, when set, are transparent; otherwise
+// the alpha of each pixel is used. Sprite implements image.Image.
+type Sprite struct {
+	Width, Height int
+	Pix           []Color
+	Indexes       []uint8
+	Palette       []Color
+	Key           *Color
+}
+
+// NewSprite returns a transparent RGBA sprite.
+func NewSprite(width, height int) *Sprite {
+	return &Sprite{
+		Width:  width,
+		Height: height,
+		Pix:    make([]Color, width*height),
+	}
+}
+
+// NewIndexedSprite returns a sprite whose pixels are all index 0 of
+// palette.
+func NewIndexedSprite(width, height int, palette []Color) *Sprite {
+	return &Sprite{
+		Width:   width,
+		Height:  height,
+		Indexes: make([]uint8, width*height),
+		Palette: palette,
+	}
+}
+
+// SpriteFromImage copies img into a new sprite. Paletted images give
+// indexed sprites.
+func SpriteFromImage(img image.Image) *Sprite {
+	b := img.Bounds()
+	if p, ok := img.(*image.Paletted); ok {
+		palette := make([]Color, len(p.Palette))
+		for j, c := range p.Palette {
+			palette[j] = toColor(c)
+		}
+		sp := NewIndexedSprite(b.Dx(), b.Dy(), palette)
+		for y := 0; y < b.Dy(); y++ {
+			copy(sp.Indexes[y*sp.Width:], p.Pix[p.PixOffset(b.Min.X, b.Min.Y+y):][:b.Dx()])
+		}
+		return sp
+	}
+	sp := NewSprite(b.Dx(), b.Dy())
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			sp.Pix[y*sp.Width+x] = toColor(img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return sp
+}
+
+// SubSprite copies the part r of sp into a new sprite sharing its palette
+// and key.
+func (sp *Sprite) SubSprite(r image.Rectangle) *Sprite {
+	r = r.Intersect(sp.Bounds())
+	sub := &Sprite{
+		Width:   r.Dx(),
+		Height:  r.Dy(),
+		Palette: sp.Palette,
+		Key:     sp.Key,
+	}
+	if sp.Palette != nil {
+		sub.Indexes = make([]uint8, sub.Width*sub.Height)
+		for y := 0; y < sub.Height; y++ {
+			copy(sub.Indexes[y*sub.Width:], sp.Indexes[(r.Min.Y+y)*sp.Width+r.Min.X:][:sub.Width])
+		}
+		return sub
+	}
+	sub.Pix = make([]Color, sub.Width*sub.Height)
+	for y := 0; y < sub.Height; y++ {
+		copy(sub.Pix[y*sub.Width:], sp.Pix[(r.Min.Y+y)*sp.Width+r.Min.X:][:sub.Width])
+	}
+	return sub
+}
+
+func toColor(c color.Color) Color {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return Color{n.R, n.G, n.B, n.A}
+}
+
+// Pixel returns the colour of pixel x, y.
+func (sp *Sprite) Pixel(x, y int) Color {
+	if sp.Palette != nil {
+		idx := int(sp.Indexes[y*sp.Width+x])
+		if idx >= len(sp.Palette) {
+			return Color{}
+		}
+		return sp.Palette[idx]
+	}
+	return sp.Pix[y*sp.Width+x]
+}
+
+// SetPixel sets pixel x, y of an RGBA sprite.
+func (sp *Sprite) SetPixel(x, y int, c Color) {
+	sp.Pix[y*sp.Width+x] = c
+}
+
+// SetIndex sets pixel x, y of an indexed sprite.
+func (sp *Sprite) SetIndex(x, y int, idx uint8) {
+	sp.Indexes[y*sp.Width+x] = idx
+}
+
+func (sp *Sprite) ColorModel() color.Model {
+	return color.NRGBAModel
+}
+
+func (sp *Sprite) Bounds() image.Rectangle {
+	return image.Rect(0, 0, sp.Width, sp.Height)
+}
+
+func (sp *Sprite) At(x, y int) color.Color {
+	if x < 0 || y < 0 || x >= sp.Width || y >= sp.Height {
+		return color.NRGBA{}
+	}
+	c := sp.Pixel(x, y)
+	return color.NRGBA{c[0], c[1], c[2], c[3]}
+}
+
+// Flip mirrors a sprite when drawn.
+type Flip int
+
+const (
+	FlipH Flip = 1 << iota
+	FlipV
+)
+
+// SpriteOptions transform a sprite drawn with DrawSpriteEx. The sprite is
+// flipped, scaled (zero means 1) and turned Rotate quarter turns clockwise,
+// in that order, and x, y is the top left corner of the result. Angle then
+// rotates it, in degrees counterclockwise, around its centre. Scaling and
+// rotation sample the nearest pixel.
+type SpriteOptions struct {
+	Flip           Flip
+	ScaleX, ScaleY float64
+	Rotate         int
+	Angle          float64
+}
+
+// DrawSprite draws sp with its top left corner at x, y.
+func (s *Surface) DrawSprite(sp *Sprite, x, y int) {
+	s.DrawSpriteEx(sp, x, y, SpriteOptions{})
+}
+
+// DrawSpriteEx draws sp transformed by opt.
+func (s *Surface) DrawSpriteEx(sp *Sprite, x, y int, opt SpriteOptions) {
+	if sp.Width <= 0 || sp.Height <= 0 {
+		return
+	}
+	sx, sy := opt.ScaleX, opt.ScaleY
+	if sx <= 0 {
+		sx = 1
+	}
+	if sy <= 0 {
+		sy = 1
+	}
+	turns := (opt.Rotate%4 + 4) % 4
+
+	// size after scaling and quarter turns
+	w, h := float64(sp.Width)*sx, float64(sp.Height)*sy
+	if turns%2 == 1 {
+		w, h = h, w
+	}
+	c := vec{float64(x) + w/2, float64(y) + h/2}
+	sin, cos := math.Sincos(opt.Angle * math.Pi / 180)
+
+	// bounding box of the rotated rectangle
+	bounds := image.Rect(x, y, x+int(math.Ceil(w)), y+int(math.Ceil(h)))
+	if opt.Angle != 0 {
+		minX, minY := math.Inf(1), math.Inf(1)
+		maxX, maxY := math.Inf(-1), math.Inf(-1)
+		for _, d := range [4]vec{{-w / 2, -h / 2}, {w / 2, -h / 2}, {w / 2, h / 2}, {-w / 2, h / 2}} {
+			px := c.x + d.x*cos + d.y*sin
+			py := c.y - d.x*sin + d.y*cos
+			minX, maxX = math.Min(minX, px), math.Max(maxX, px)
+			minY, maxY = math.Min(minY, py), math.Max(maxY, py)
+		}
+		bounds = image.Rect(int(math.Floor(minX)), int(math.Floor(minY)), int(math.Ceil(maxX)), int(math.Ceil(maxY)))
+	}
+	bounds = bounds.Intersect(s.clip)
+
+	defer s.once()()
+	for py := bounds.Min.Y; py < bounds.Max.Y; py++ {
+		for px := bounds.Min.X; px < bounds.Max.X; px++ {
+			p := center(px, py)
+			if opt.Angle != 0 {
+				d := p.sub(c)
+				p = vec{c.x + d.x*cos - d.y*sin, c.y + d.x*sin + d.y*cos}
+			}
+			u, v := p.x-float64(x), p.y-float64(y)
+			tw, th := w, h
+			for t := 0; t < turns; t++ {
+				// undo a clockwise quarter turn of a tw by th box
+				u, v = v, tw-u
+				tw, th = th, tw
+			}
+			u, v = u/sx, v/sy
+			if u < 0 || v < 0 {
+				continue
+			}
+			ix, iy := int(u), int(v)
+			if ix >= sp.Width || iy >= sp.Height {
+				continue
+			}
+			if opt.Flip&FlipH != 0 {
+				ix = sp.Width - 1 - ix
+			}
+			if opt.Flip&FlipV != 0 {
+				iy = sp.Height - 1 - iy
+			}
+			col := sp.Pixel(ix, iy)
+			if sp.Key != nil && col == *sp.Key {
+				continue
+			}
+			if col[3] == 0 && s.Blend == BlendOver {
+				continue
+			}
+			s.setPix(px, py, col)
+		}
+	}
+}
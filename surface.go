@@ -0,0 +1,226 @@
+package graphos
+
+import (
+	"image"
+	"image/color"
+
+	"crg.eti.br/go/graphos/fonts"
+)
+
+// Surface is an RGBA pixel buffer with the graphos drawing primitives. The
+// screen of an Instance is a Surface; offscreen surfaces created with
+// NewSurface can be drawn on the same way and composited with Blit.
+// Surface implements draw.Image.
+type Surface struct {
+	CurrentColor Color
+	Blend        BlendMode
+	// LineWidth is the width of lines, boxes and polylines in pixels. One
+	// or less draws the classic single pixel Bresenham lines.
+	LineWidth float64
+	LineCap   LineCap
+	LineJoin  LineJoin
+	// LinePattern, LineScale and LinePhase dash outlines; see
+	// LinePattern's constants.
+	LinePattern uint16
+	LineScale   int
+	LinePhase   int
+	// FillStyle paints filled shapes instead of their solid colour when
+	// set.
+	FillStyle Paint
+	img       *image.RGBA
+	dirty     image.Rectangle
+	clip      image.Rectangle
+
+	// per pixel generation of the last write, see once
+	stamp     []uint32
+	gen       uint32
+	onceDepth int
+}
+
+func NewSurface(width, height int) *Surface {
+	s := &Surface{
+		CurrentColor: Colors16[0x0F],
+		img:          image.NewRGBA(image.Rect(0, 0, width, height)),
+	}
+	s.ResetClip()
+	return s
+}
+
+// Image returns the pixel buffer. It is shared with the surface, so it
+// changes as the surface is drawn on.
+func (s *Surface) Image() *image.RGBA {
+	return s.img
+}
+
+func (s *Surface) ColorModel() color.Model {
+	return color.RGBAModel
+}
+
+func (s *Surface) Bounds() image.Rectangle {
+	return s.img.Rect
+}
+
+func (s *Surface) At(x, y int) color.Color {
+	return s.img.At(x, y)
+}
+
+// Set stores c as is, like image.RGBA, whatever the blend mode, so that
+// draw.Draw with draw.Src copies its source. Pixels outside the clip
+// rectangle are ignored.
+func (s *Surface) Set(x, y int, c color.Color) {
+	if !s.inClip(x, y) {
+		return
+	}
+	r, g, b, a := c.RGBA()
+	pos := s.img.PixOffset(x, y)
+	pix := s.img.Pix[pos : pos+4]
+	pix[0] = uint8(r >> 8)
+	pix[1] = uint8(g >> 8)
+	pix[2] = uint8(b >> 8)
+	pix[3] = uint8(a >> 8)
+	s.damagePix(x, y)
+}
+
+// DrawPix sets one pixel. Pixels outside the clip rectangle are ignored.
+func (s *Surface) DrawPix(x, y int, color Color) {
+	if !s.inClip(x, y) {
+		return
+	}
+	s.setPix(x, y, color)
+}
+
+// setPix sets a pixel known to be inside the clip rectangle.
+func (s *Surface) setPix(x, y int, color Color) {
+	pos := s.img.Stride*y + 4*x
+
+	s.blend(pos, color)
+	s.damagePix(x, y)
+}
+
+// damage adds r to the region that changed since the surface was last
+// presented.
+func (s *Surface) damage(r image.Rectangle) {
+	s.dirty = s.dirty.Union(r)
+}
+
+func (s *Surface) damagePix(x, y int) {
+	d := &s.dirty
+	if d.Empty() {
+		*d = image.Rect(x, y, x+1, y+1)
+		return
+	}
+	if x < d.Min.X {
+		d.Min.X = x
+	} else if x >= d.Max.X {
+		d.Max.X = x + 1
+	}
+	if y < d.Min.Y {
+		d.Min.Y = y
+	} else if y >= d.Max.Y {
+		d.Max.Y = y + 1
+	}
+}
+
+func (s *Surface) DrawChar(index, fgColor, bgColor byte, x, y int) {
+	if !image.Rect(x, y, x+9, y+16).Overlaps(s.clip) {
+		return
+	}
+	var a uint
+	var b uint
+	var lColor Color
+	for b = 0; b < 16; b++ {
+		for a = 0; a < 9; a++ {
+			x1 := int(a) + x
+			y1 := int(b) + y
+			if a == 8 {
+				c := Colors16[bgColor]
+				if index >= 192 && index <= 223 {
+					c = lColor
+				}
+				s.DrawPix(x1, y1, c)
+				continue
+			}
+			idx := uint(index)*16 + b
+			if fonts.Bitmap[idx]&(0x80>>a) != 0 {
+				lColor = Colors16[fgColor]
+				s.DrawPix(x1, y1, lColor)
+				continue
+			}
+			lColor = Colors16[bgColor]
+			s.DrawPix(x1, y1, lColor)
+		}
+	}
+}
+
+func (s *Surface) DrawString(text string, fgColor, bgColor byte, x, y int) {
+	for idx := 0; idx < len(text); idx++ {
+		s.DrawChar(text[idx], fgColor, bgColor, x, y)
+		x += 9
+	}
+}
+
+// Clear fills the clip rectangle with CurrentColor, ignoring the blend mode.
+func (s *Surface) Clear() {
+	if s.clip != s.img.Rect {
+		fill := s.FillStyle
+		s.FillStyle = nil
+		s.WithBlend(BlendReplace, func() {
+			s.DrawFilledBox(s.clip.Min.X, s.clip.Min.Y, s.clip.Max.X-1, s.clip.Max.Y-1, s.CurrentColor)
+		})
+		s.FillStyle = fill
+		return
+	}
+	color := premultiply(s.CurrentColor)
+	pix := s.img.Pix
+	lenPix := len(pix)
+
+	copy(pix, color[:])
+
+	for j := 4; j < lenPix; j *= 2 {
+		copy(pix[j:], pix[:j])
+	}
+	s.damage(s.img.Rect)
+}
+
+// Blit copies the rectangle r of src to s with its top-left corner at x, y.
+// src and s may be the same surface.
+func (s *Surface) Blit(src *Surface, r image.Rectangle, x, y int) {
+	s.blit(src, r, x, y, nil)
+}
+
+// BlitKey is like Blit but skips the source pixels equal to key, so they
+// stay transparent.
+func (s *Surface) BlitKey(src *Surface, r image.Rectangle, x, y int, key Color) {
+	s.blit(src, r, x, y, &key)
+}
+
+func (s *Surface) blit(src *Surface, r image.Rectangle, x, y int, key *Color) {
+	r = r.Intersect(src.img.Rect)
+	dst := r.Add(image.Pt(x, y).Sub(r.Min)).Intersect(s.clip)
+	if dst.Empty() {
+		return
+	}
+	r.Min = r.Min.Add(dst.Min.Sub(image.Pt(x, y)))
+	r.Max = r.Min.Add(dst.Size())
+	rowLen := 4 * r.Dx()
+
+	y0, y1, dy := 0, r.Dy(), 1
+	if src == s && dst.Min.Y > r.Min.Y {
+		y0, y1, dy = r.Dy()-1, -1, -1
+	}
+	for row := y0; row != y1; row += dy {
+		sp := src.img.Pix[src.img.PixOffset(r.Min.X, r.Min.Y+row):]
+		dp := s.img.Pix[s.img.PixOffset(dst.Min.X, dst.Min.Y+row):]
+		if key == nil {
+			copy(dp[:rowLen], sp[:rowLen])
+			continue
+		}
+		for j := 0; j < rowLen; j += 4 {
+			if sp[j] == key[0] && sp[j+1] == key[1] && sp[j+2] == key[2] && sp[j+3] == key[3] {
+				continue
+			}
+			copy(dp[j:j+4], sp[j:j+4])
+		}
+	}
+	s.damage(dst)
+}
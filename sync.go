@@ -0,0 +1,65 @@
+package graphos
+
+// An Instance is driven by its video backend: Update runs ScreenHandler and
+// Present hands the framebuffer to the backend, both with the frame lock
+// held. Drawing from ScreenHandler therefore needs no synchronization.
+//
+// Other goroutines (emulators, simulations, loaders) have two options:
+//
+//   - Wrap every access to the instance, its screen and any state that
+//     ScreenHandler reads in Lock/Unlock. Frames are never presented
+//     half drawn.
+//   - Draw on the surface returned by Back, which only that goroutine
+//     touches, and publish it with Swap once the frame is complete.
+//
+// Lock, Unlock, Back and Swap are safe to call from any goroutine, but
+// never from ScreenHandler, which already holds the lock. All other
+// methods and fields of Instance and its Surface must only be used from
+// ScreenHandler or with the lock held.
+
+// Lock acquires the frame lock, blocking Update and Present until Unlock.
+func (i *Instance) Lock() {
+	i.mu.Lock()
+}
+
+func (i *Instance) Unlock() {
+	i.mu.Unlock()
+}
+
+// setRunning sets Running under the frame lock, so that goroutines reading
+// it with the lock held see the loop start and stop.
+func (i *Instance) setRunning(running bool) {
+	i.mu.Lock()
+	i.Running = running
+	i.mu.Unlock()
+}
+
+// Back returns the back buffer, a surface the size of the screen that is
+// allocated on first use. It belongs to the drawing goroutine until Swap.
+func (i *Instance) Back() *Surface {
+	i.backOnce.Do(func() {
+		i.mu.Lock()
+		i.back = NewSurface(i.Width, i.Height)
+		i.mu.Unlock()
+	})
+	return i.back
+}
+
+// Swap exchanges the back buffer with the screen under the frame lock and
+// schedules the new screen to be presented. After Swap the back buffer
+// holds the previously shown frame. When the screen was resized since the
+// last Swap, the frame is cropped or padded to the new size and the back
+// buffer comes back at that size.
+func (i *Instance) Swap() {
+	b := i.Back()
+
+	i.mu.Lock()
+	if b.img.Rect != i.img.Rect {
+		b.img = resizeRGBA(b.img, i.img.Rect)
+		b.ResetClip()
+	}
+	i.img, b.img = b.img, i.img
+	i.damage(i.img.Rect)
+	i.UpdateScreen = true
+	i.mu.Unlock()
+}
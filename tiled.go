@@ -0,0 +1,471 @@
+package graphos
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Tiled stores flips in the high bits of each global tile id.
+const (
+	tiledFlipH = 0x80000000
+	tiledFlipV = 0x40000000
+	tiledFlipD = 0x20000000
+	tiledGID   = 0x0FFFFFFF
+)
+
+// tiledTileset and tiledLayer are what graphos uses of a Tiled map, from
+// either file format.
+type tiledTileset struct {
+	firstGID              int
+	source                string
+	tileWidth, tileHeight int
+	margin, spacing       int
+	image, trans          string
+}
+
+type tiledLayer struct {
+	name                 string
+	width, height        int
+	hidden               bool
+	offsetX, offsetY     float64
+	parallaxX, parallaxY float64
+	gids                 []uint32
+}
+
+// LoadTiled loads an orthogonal Tiled map saved as TMX (.tmx) or JSON
+// (.tmj, .json) from fsys. External tilesets and tileset images are read
+// from fsys relative to the file referring to them, with opt passed to
+// LoadSprite. Tile layers, including those inside groups, become the
+// layers of the tilemap; object and image layers are skipped.
+func LoadTiled(fsys fs.FS, name string, opt *LoadOptions) (*Tilemap, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %v: %v", name, err)
+	}
+
+	var (
+		tw, th   int
+		tilesets []tiledTileset
+		layers   []tiledLayer
+	)
+	switch strings.ToLower(path.Ext(name)) {
+	case ".tmx":
+		tw, th, tilesets, layers, err = parseTMX(data)
+	default:
+		tw, th, tilesets, layers, err = parseTMJ(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%v, error: %v", name, err)
+	}
+
+	ts := &Tileset{TileWidth: tw, TileHeight: th}
+	dir := path.Dir(name)
+	for _, t := range tilesets {
+		if t.firstGID < 1 {
+			return nil, fmt.Errorf("%v, error: invalid firstgid %v", name, t.firstGID)
+		}
+		base := dir
+		if t.source != "" {
+			src := path.Join(dir, t.source)
+			first := t.firstGID
+			t, err = loadTiledTileset(fsys, src)
+			if err != nil {
+				return nil, err
+			}
+			t.firstGID = first
+			base = path.Dir(src)
+		}
+		if t.image == "" {
+			return nil, fmt.Errorf("%v, error: tileset without a single image", name)
+		}
+		sheet, err := LoadSprite(fsys, path.Join(base, t.image), opt)
+		if err != nil {
+			return nil, err
+		}
+		if t.trans != "" {
+			key, err := parseHexColor(t.trans)
+			if err != nil {
+				return nil, fmt.Errorf("%v, error: %v", name, err)
+			}
+			sheet.Key = &key
+		}
+		tiles := NewTileset(sheet, t.tileWidth, t.tileHeight, t.margin, t.spacing).Tiles
+		for j, sp := range tiles {
+			idx := t.firstGID + j - 1
+			for len(ts.Tiles) <= idx {
+				ts.Tiles = append(ts.Tiles, nil)
+			}
+			ts.Tiles[idx] = sp
+		}
+	}
+
+	m := &Tilemap{TileWidth: tw, TileHeight: th, Tileset: ts}
+	for _, tl := range layers {
+		if len(tl.gids) != tl.width*tl.height {
+			return nil, fmt.Errorf("%v, error: layer %q has %v tiles, want %v", name, tl.name, len(tl.gids), tl.width*tl.height)
+		}
+		l := NewTileLayer(tl.width, tl.height)
+		l.Name = tl.name
+		l.Hidden = tl.hidden
+		l.OffsetX, l.OffsetY = tl.offsetX, tl.offsetY
+		l.ParallaxX, l.ParallaxY = tl.parallaxX, tl.parallaxY
+		for j, gid := range tl.gids {
+			t := Tile{Index: int(gid & tiledGID)}
+			if gid&tiledFlipH != 0 {
+				t.Flags |= TileFlipH
+			}
+			if gid&tiledFlipV != 0 {
+				t.Flags |= TileFlipV
+			}
+			if gid&tiledFlipD != 0 {
+				t.Flags |= TileFlipD
+			}
+			l.Tiles[j] = t
+		}
+		m.Layers = append(m.Layers, l)
+	}
+	return m, nil
+}
+
+func loadTiledTileset(fsys fs.FS, name string) (tiledTileset, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return tiledTileset{}, fmt.Errorf("error reading %v: %v", name, err)
+	}
+	var t tiledTileset
+	if strings.ToLower(path.Ext(name)) == ".tsx" {
+		var x tmxTileset
+		err = xml.Unmarshal(data, &x)
+		t = x.tileset()
+	} else {
+		var j tmjTileset
+		err = json.Unmarshal(data, &j)
+		t = j.tileset()
+	}
+	if err != nil {
+		return tiledTileset{}, fmt.Errorf("%v, error: %v", name, err)
+	}
+	return t, nil
+}
+
+func parseHexColor(s string) (Color, error) {
+	s = strings.TrimPrefix(s, "#")
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil || (len(s) != 6 && len(s) != 8) {
+		return Color{}, fmt.Errorf("invalid colour %q", s)
+	}
+	c := Color{uint8(v >> 16), uint8(v >> 8), uint8(v), 0xFF}
+	if len(s) == 8 {
+		c = Color{uint8(v >> 16), uint8(v >> 8), uint8(v), uint8(v >> 24)}
+	}
+	return c, nil
+}
+
+// decodeTiledData decodes the tile ids of a layer stored as CSV or as
+// base64, optionally zlib or gzip compressed.
+func decodeTiledData(encoding, compression, text string) ([]uint32, error) {
+	text = strings.TrimSpace(text)
+	switch encoding {
+	case "csv":
+		var gids []uint32
+		for _, f := range strings.Split(text, ",") {
+			f = strings.TrimSpace(f)
+			if f == "" {
+				continue
+			}
+			v, err := strconv.ParseUint(f, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid tile %q", f)
+			}
+			gids = append(gids, uint32(v))
+		}
+		return gids, nil
+	case "base64":
+	default:
+		return nil, fmt.Errorf("unsupported encoding %q", encoding)
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 data: %v", err)
+	}
+	var r io.Reader = bytes.NewReader(raw)
+	switch compression {
+	case "":
+	case "zlib":
+		r, err = zlib.NewReader(r)
+	case "gzip":
+		r, err = gzip.NewReader(r)
+	default:
+		return nil, fmt.Errorf("unsupported compression %q", compression)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid %v data: %v", compression, err)
+	}
+	raw, err = io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %v data: %v", compression, err)
+	}
+	gids := make([]uint32, len(raw)/4)
+	for j := range gids {
+		gids[j] = binary.LittleEndian.Uint32(raw[4*j:])
+	}
+	return gids, nil
+}
+
+// TMX, the XML format
+
+type tmxMap struct {
+	Orientation string       `xml:"orientation,attr"`
+	Infinite    int          `xml:"infinite,attr"`
+	TileWidth   int          `xml:"tilewidth,attr"`
+	TileHeight  int          `xml:"tileheight,attr"`
+	Tilesets    []tmxTileset `xml:"tileset"`
+	Layers      []tmxLayer   `xml:",any"`
+}
+
+type tmxTileset struct {
+	FirstGID   int    `xml:"firstgid,attr"`
+	Source     string `xml:"source,attr"`
+	TileWidth  int    `xml:"tilewidth,attr"`
+	TileHeight int    `xml:"tileheight,attr"`
+	Margin     int    `xml:"margin,attr"`
+	Spacing    int    `xml:"spacing,attr"`
+	Image      struct {
+		Source string `xml:"source,attr"`
+		Trans  string `xml:"trans,attr"`
+	} `xml:"image"`
+}
+
+func (t tmxTileset) tileset() tiledTileset {
+	return tiledTileset{
+		firstGID:   t.FirstGID,
+		source:     t.Source,
+		tileWidth:  t.TileWidth,
+		tileHeight: t.TileHeight,
+		margin:     t.Margin,
+		spacing:    t.Spacing,
+		image:      t.Image.Source,
+		trans:      t.Image.Trans,
+	}
+}
+
+// tmxLayer is any element inside a map or group; only layers and groups
+// are used.
+type tmxLayer struct {
+	XMLName   xml.Name
+	Name      string   `xml:"name,attr"`
+	Width     int      `xml:"width,attr"`
+	Height    int      `xml:"height,attr"`
+	Visible   *int     `xml:"visible,attr"`
+	OffsetX   float64  `xml:"offsetx,attr"`
+	OffsetY   float64  `xml:"offsety,attr"`
+	ParallaxX *float64 `xml:"parallaxx,attr"`
+	ParallaxY *float64 `xml:"parallaxy,attr"`
+	Data      struct {
+		Encoding    string `xml:"encoding,attr"`
+		Compression string `xml:"compression,attr"`
+		Text        string `xml:",chardata"`
+		Tiles       []struct {
+			GID uint32 `xml:"gid,attr"`
+		} `xml:"tile"`
+	} `xml:"data"`
+	Layers []tmxLayer `xml:",any"`
+}
+
+func parseTMX(data []byte) (int, int, []tiledTileset, []tiledLayer, error) {
+	var m tmxMap
+	err := xml.Unmarshal(data, &m)
+	if err != nil {
+		return 0, 0, nil, nil, err
+	}
+	if m.Orientation != "orthogonal" || m.Infinite != 0 {
+		return 0, 0, nil, nil, fmt.Errorf("only finite orthogonal maps are supported")
+	}
+	var tilesets []tiledTileset
+	for _, t := range m.Tilesets {
+		tilesets = append(tilesets, t.tileset())
+	}
+	parent := tiledLayer{parallaxX: 1, parallaxY: 1}
+	layers, err := tmxLayers(m.Layers, parent, nil)
+	return m.TileWidth, m.TileHeight, tilesets, layers, err
+}
+
+// tmxLayers flattens layers and groups, combining each layer's visibility,
+// offset and parallax with those of the groups it is in.
+func tmxLayers(in []tmxLayer, parent tiledLayer, out []tiledLayer) ([]tiledLayer, error) {
+	for _, x := range in {
+		if x.XMLName.Local != "layer" && x.XMLName.Local != "group" {
+			continue
+		}
+		l := tiledLayer{
+			name:      x.Name,
+			width:     x.Width,
+			height:    x.Height,
+			hidden:    parent.hidden || (x.Visible != nil && *x.Visible == 0),
+			offsetX:   parent.offsetX + x.OffsetX,
+			offsetY:   parent.offsetY + x.OffsetY,
+			parallaxX: parent.parallaxX,
+			parallaxY: parent.parallaxY,
+		}
+		if x.ParallaxX != nil {
+			l.parallaxX *= *x.ParallaxX
+		}
+		if x.ParallaxY != nil {
+			l.parallaxY *= *x.ParallaxY
+		}
+
+		if x.XMLName.Local == "group" {
+			var err error
+			out, err = tmxLayers(x.Layers, l, out)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if x.Data.Encoding == "" {
+			for _, t := range x.Data.Tiles {
+				l.gids = append(l.gids, t.GID)
+			}
+		} else {
+			var err error
+			l.gids, err = decodeTiledData(x.Data.Encoding, x.Data.Compression, x.Data.Text)
+			if err != nil {
+				return nil, fmt.Errorf("layer %q: %v", x.Name, err)
+			}
+		}
+		out = append(out, l)
+	}
+	return out, nil
+}
+
+// TMJ, the JSON format
+
+type tmjMap struct {
+	Orientation string       `json:"orientation"`
+	Infinite    bool         `json:"infinite"`
+	TileWidth   int          `json:"tilewidth"`
+	TileHeight  int          `json:"tileheight"`
+	Tilesets    []tmjTileset `json:"tilesets"`
+	Layers      []tmjLayer   `json:"layers"`
+}
+
+type tmjTileset struct {
+	FirstGID         int    `json:"firstgid"`
+	Source           string `json:"source"`
+	TileWidth        int    `json:"tilewidth"`
+	TileHeight       int    `json:"tileheight"`
+	Margin           int    `json:"margin"`
+	Spacing          int    `json:"spacing"`
+	Image            string `json:"image"`
+	TransparentColor string `json:"transparentcolor"`
+}
+
+func (t tmjTileset) tileset() tiledTileset {
+	return tiledTileset{
+		firstGID:   t.FirstGID,
+		source:     t.Source,
+		tileWidth:  t.TileWidth,
+		tileHeight: t.TileHeight,
+		margin:     t.Margin,
+		spacing:    t.Spacing,
+		image:      t.Image,
+		trans:      t.TransparentColor,
+	}
+}
+
+type tmjLayer struct {
+	Type        string          `json:"type"`
+	Name        string          `json:"name"`
+	Width       int             `json:"width"`
+	Height      int             `json:"height"`
+	Visible     *bool           `json:"visible"`
+	OffsetX     float64         `json:"offsetx"`
+	OffsetY     float64         `json:"offsety"`
+	ParallaxX   *float64        `json:"parallaxx"`
+	ParallaxY   *float64        `json:"parallaxy"`
+	Encoding    string          `json:"encoding"`
+	Compression string          `json:"compression"`
+	Data        json.RawMessage `json:"data"`
+	Layers      []tmjLayer      `json:"layers"`
+}
+
+func parseTMJ(data []byte) (int, int, []tiledTileset, []tiledLayer, error) {
+	var m tmjMap
+	err := json.Unmarshal(data, &m)
+	if err != nil {
+		return 0, 0, nil, nil, err
+	}
+	if m.Orientation != "orthogonal" || m.Infinite {
+		return 0, 0, nil, nil, fmt.Errorf("only finite orthogonal maps are supported")
+	}
+	var tilesets []tiledTileset
+	for _, t := range m.Tilesets {
+		tilesets = append(tilesets, t.tileset())
+	}
+	parent := tiledLayer{parallaxX: 1, parallaxY: 1}
+	layers, err := tmjLayers(m.Layers, parent, nil)
+	return m.TileWidth, m.TileHeight, tilesets, layers, err
+}
+
+// tmjLayers is tmxLayers for the JSON format.
+func tmjLayers(in []tmjLayer, parent tiledLayer, out []tiledLayer) ([]tiledLayer, error) {
+	for _, x := range in {
+		if x.Type != "tilelayer" && x.Type != "group" {
+			continue
+		}
+		l := tiledLayer{
+			name:      x.Name,
+			width:     x.Width,
+			height:    x.Height,
+			hidden:    parent.hidden || (x.Visible != nil && !*x.Visible),
+			offsetX:   parent.offsetX + x.OffsetX,
+			offsetY:   parent.offsetY + x.OffsetY,
+			parallaxX: parent.parallaxX,
+			parallaxY: parent.parallaxY,
+		}
+		if x.ParallaxX != nil {
+			l.parallaxX *= *x.ParallaxX
+		}
+		if x.ParallaxY != nil {
+			l.parallaxY *= *x.ParallaxY
+		}
+
+		if x.Type == "group" {
+			var err error
+			out, err = tmjLayers(x.Layers, l, out)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		var err error
+		if x.Encoding == "base64" {
+			var text string
+			err = json.Unmarshal(x.Data, &text)
+			if err == nil {
+				l.gids, err = decodeTiledData(x.Encoding, x.Compression, text)
+			}
+		} else {
+			err = json.Unmarshal(x.Data, &l.gids)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("layer %q: %v", x.Name, err)
+		}
+		out = append(out, l)
+	}
+	return out, nil
+}
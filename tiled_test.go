@@ -0,0 +1,129 @@
+package graphos
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// tiledSheet returns a PNG of 2x2 tiles of 8x8 pixels.
+func tiledSheet(t *testing.T) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, 16, 16))
+	cols := []color.NRGBA{{255, 0, 0, 255}, {0, 255, 0, 255}, {0, 0, 255, 255}, {255, 0, 255, 255}}
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 16; x++ {
+			img.Set(x, y, cols[y/8*2+x/8])
+		}
+	}
+	var buf bytes.Buffer
+	err := png.Encode(&buf, img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// zlibBase64 encodes gids the way Tiled stores compressed layers.
+func zlibBase64(gids ...uint32) string {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	binary.Write(w, binary.LittleEndian, gids)
+	w.Close()
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+const tmxLevel = `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="orthogonal" renderorder="right-down" width="2" height="2" tilewidth="8" tileheight="8" infinite="0">
+ <tileset firstgid="1" source="tiles/set.tsx"/>
+ <layer id="1" name="bg" width="2" height="2" parallaxx="0.5">
+  <data encoding="csv">
+1,2,
+3,4
+</data>
+ </layer>
+ <objectgroup id="2" name="objects"/>
+ <group name="front" offsetx="2">
+  <layer id="3" name="fg" width="2" height="2">
+   <data encoding="base64" compression="zlib">DATA</data>
+  </layer>
+ </group>
+</map>`
+
+const tsxSet = `<?xml version="1.0" encoding="UTF-8"?>
+<tileset version="1.10" name="set" tilewidth="8" tileheight="8" tilecount="4" columns="2">
+ <image source="../img/sheet.png" width="16" height="16"/>
+</tileset>`
+
+const tmjLevel = `{"orientation": "orthogonal", "infinite": false,
+ "tilewidth": 8, "tileheight": 8, "width": 2, "height": 2,
+ "tilesets": [{"firstgid": FIRST, "image": "img/sheet.png", "tilewidth": 8, "tileheight": 8}],
+ "layers": [{"type": "tilelayer", "name": "bg", "width": 2, "height": 2,
+  "data": [2147483652, 1073741827, 536870914, 3758096385]}]}`
+
+func TestLoadTiled(t *testing.T) {
+	fsys := fstest.MapFS{
+		"maps/level.tmx":     {Data: []byte(strings.Replace(tmxLevel, "DATA", zlibBase64(0, 4, 0, 1), 1))},
+		"maps/tiles/set.tsx": {Data: []byte(tsxSet)},
+		"maps/img/sheet.png": {Data: tiledSheet(t)},
+		"maps/level.tmj":     {Data: []byte(strings.Replace(tmjLevel, "FIRST", "1", 1))},
+	}
+
+	m, err := LoadTiled(fsys, "maps/level.tmx", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Tileset.Tiles) != 4 || len(m.Layers) != 2 {
+		t.Fatalf("got %v tiles and %v layers, want 4 and 2", len(m.Tileset.Tiles), len(m.Layers))
+	}
+	bg, fg := m.Layer("bg"), m.Layer("fg")
+	if bg.ParallaxX != 0.5 || fg.OffsetX != 2 {
+		t.Errorf("bg parallax %v, fg offset %v, want 0.5 and 2", bg.ParallaxX, fg.OffsetX)
+	}
+	for j, want := range []int{1, 2, 3, 4} {
+		if got := bg.Tiles[j].Index; got != want {
+			t.Errorf("csv tile %v is %v, want %v", j, got, want)
+		}
+	}
+	for j, want := range []int{0, 4, 0, 1} {
+		if got := fg.Tiles[j].Index; got != want {
+			t.Errorf("zlib tile %v is %v, want %v", j, got, want)
+		}
+	}
+	if p := m.Tileset.Tiles[1].Pixel(0, 0); p != (Color{0, 255, 0, 255}) {
+		t.Errorf("tile 2 is %v, want green", p)
+	}
+
+	m, err = LoadTiled(fsys, "maps/level.tmj", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Tile{
+		{4, TileFlipH},
+		{3, TileFlipV},
+		{2, TileFlipD},
+		{1, TileFlipH | TileFlipV | TileFlipD},
+	}
+	for j, w := range want {
+		if got := m.Layers[0].Tiles[j]; got != w {
+			t.Errorf("tmj tile %v is %+v, want %+v", j, got, w)
+		}
+	}
+}
+
+func TestLoadTiledBadFirstGID(t *testing.T) {
+	fsys := fstest.MapFS{
+		"level.tmj":     {Data: []byte(strings.Replace(tmjLevel, "FIRST", "0", 1))},
+		"img/sheet.png": {Data: tiledSheet(t)},
+	}
+	_, err := LoadTiled(fsys, "level.tmj", nil)
+	if err == nil {
+		t.Fatal("firstgid 0 did not fail")
+	}
+}
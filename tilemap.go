@@ -0,0 +1,188 @@
+package graphos
+
+import (
+	"image"
+	"math"
+)
+
+// Tileset is a sprite sheet sliced into tiles.
+type Tileset struct {
+	TileWidth, TileHeight int
+	Tiles                 []*Sprite
+}
+
+// NewTileset slices sheet into tiles of tileWidth by tileHeight pixels,
+// left to right and top to bottom. margin is the border around the sheet
+// and spacing the gap between tiles, as in Tiled.
+func NewTileset(sheet *Sprite, tileWidth, tileHeight, margin, spacing int) *Tileset {
+	ts := &Tileset{TileWidth: tileWidth, TileHeight: tileHeight}
+	if tileWidth <= 0 || tileHeight <= 0 {
+		return ts
+	}
+	for y := margin; y+tileHeight <= sheet.Height-margin; y += tileHeight + spacing {
+		for x := margin; x+tileWidth <= sheet.Width-margin; x += tileWidth + spacing {
+			ts.Tiles = append(ts.Tiles, sheet.SubSprite(image.Rect(x, y, x+tileWidth, y+tileHeight)))
+		}
+	}
+	return ts
+}
+
+// TileFlags are per tile flags of a tilemap cell. The flip flags follow
+// Tiled: TileFlipD swaps the x and y axes and is applied before the other
+// two. Bits from TileUser up are free for the game, for example to mark
+// solid tiles.
+type TileFlags uint8
+
+const (
+	TileFlipH TileFlags = 1 << iota
+	TileFlipV
+	TileFlipD
+	TileUser
+)
+
+// Tile is a cell of a tile layer. Index 0 is empty and index n draws
+// Tiles[n-1] of the tileset.
+type Tile struct {
+	Index int
+	Flags TileFlags
+}
+
+// TileLayer is a grid of tiles stored row by row. It is drawn shifted by
+// OffsetX, OffsetY and scrolls at ParallaxX, ParallaxY times the camera
+// speed, so layers below 1 look further away.
+type TileLayer struct {
+	Name                 string
+	Width, Height        int
+	Tiles                []Tile
+	Hidden               bool
+	OffsetX, OffsetY     float64
+	ParallaxX, ParallaxY float64
+}
+
+func NewTileLayer(width, height int) *TileLayer {
+	return &TileLayer{
+		Width:     width,
+		Height:    height,
+		Tiles:     make([]Tile, width*height),
+		ParallaxX: 1,
+		ParallaxY: 1,
+	}
+}
+
+// At returns the tile at x, y, or an empty tile outside the layer.
+func (l *TileLayer) At(x, y int) Tile {
+	if x < 0 || y < 0 || x >= l.Width || y >= l.Height {
+		return Tile{}
+	}
+	return l.Tiles[y*l.Width+x]
+}
+
+func (l *TileLayer) Set(x, y int, t Tile) {
+	if x < 0 || y < 0 || x >= l.Width || y >= l.Height {
+		return
+	}
+	l.Tiles[y*l.Width+x] = t
+}
+
+// Tilemap is a stack of tile layers drawn from the first to the last.
+// TileWidth and TileHeight are the grid size; larger tiles stick out
+// upwards from their cell.
+type Tilemap struct {
+	TileWidth, TileHeight int
+	Tileset               *Tileset
+	Layers                []*TileLayer
+}
+
+// Layer returns the first layer with the given name, or nil.
+func (m *Tilemap) Layer(name string) *TileLayer {
+	for _, l := range m.Layers {
+		if l.Name == name {
+			return l
+		}
+	}
+	return nil
+}
+
+// options returns how to draw a tile with flags f.
+func (f TileFlags) options() SpriteOptions {
+	var opt SpriteOptions
+	if f&TileFlipD == 0 {
+		if f&TileFlipH != 0 {
+			opt.Flip |= FlipH
+		}
+		if f&TileFlipV != 0 {
+			opt.Flip |= FlipV
+		}
+		return opt
+	}
+	// swapping the axes is a vertical flip and a clockwise quarter turn;
+	// flips after the turn are the other flip before it
+	opt.Rotate = 1
+	opt.Flip = FlipV
+	if f&TileFlipH != 0 {
+		opt.Flip ^= FlipV
+	}
+	if f&TileFlipV != 0 {
+		opt.Flip ^= FlipH
+	}
+	return opt
+}
+
+// DrawTilemap draws the visible layers of m into view, scrolled so that
+// map pixel x, y is at the top left corner of view.
+func (s *Surface) DrawTilemap(m *Tilemap, view image.Rectangle, x, y int) {
+	if m.Tileset == nil || m.TileWidth <= 0 || m.TileHeight <= 0 {
+		return
+	}
+	clip := s.clip
+	s.SetClip(view.Intersect(clip))
+	defer s.SetClip(clip)
+	if s.clip.Empty() {
+		return
+	}
+	tw, th := m.TileWidth, m.TileHeight
+
+	// rows below the view may hold tall tiles that reach into it
+	extra := 0
+	for _, t := range m.Tileset.Tiles {
+		if t != nil && t.Height-th > extra {
+			extra = t.Height - th
+		}
+	}
+
+	for _, l := range m.Layers {
+		if l.Hidden {
+			continue
+		}
+		// map pixel at the top left corner of view for this layer
+		ox := int(math.Floor(float64(x)*l.ParallaxX - l.OffsetX))
+		oy := int(math.Floor(float64(y)*l.ParallaxY - l.OffsetY))
+
+		c0 := floorDiv(ox, tw)
+		r0 := floorDiv(oy, th)
+		c1 := floorDiv(ox+view.Dx()-1, tw)
+		r1 := floorDiv(oy+view.Dy()-1+extra, th)
+		c0, r0 = max(c0, 0), max(r0, 0)
+		c1, r1 = min(c1, l.Width-1), min(r1, l.Height-1)
+
+		for r := r0; r <= r1; r++ {
+			for c := c0; c <= c1; c++ {
+				t := l.Tiles[r*l.Width+c]
+				if t.Index <= 0 || t.Index > len(m.Tileset.Tiles) {
+					continue
+				}
+				sp := m.Tileset.Tiles[t.Index-1]
+				if sp == nil {
+					continue
+				}
+				px := view.Min.X + c*tw - ox
+				py := view.Min.Y + (r+1)*th - sp.Height - oy
+				if t.Flags&(TileFlipH|TileFlipV|TileFlipD) == 0 {
+					s.DrawSprite(sp, px, py)
+					continue
+				}
+				s.DrawSpriteEx(sp, px, py, t.Flags.options())
+			}
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package graphos
+
+import (
+	"image"
+	"math"
+)
+
+// Turtle is a Logo style turtle drawing on an Instance with DrawLine, so
+// LineWidth, LinePattern and the write mode apply. Positions are screen
+// pixels and headings degrees counterclockwise from three o'clock; a new
+// turtle starts at the centre of the screen facing up with the pen down.
+//
+// With a zero Speed every command draws at once, or on the first tick when
+// the instance is not running yet. Otherwise commands are queued and the
+// turtle moves Speed pixels per tick of the instance, drawing each line a
+// bit at a time into the same pixels; thick lines appear whole once the
+// turtle reaches their end. Position and Heading always report where the
+// turtle will be once the queue is done. When Visible, the turtle is drawn
+// each tick as Shape, or a small triangle when Shape is nil, pointing up
+// before rotation.
+type Turtle struct {
+	Speed   float64
+	Visible bool
+	Shape   *Sprite
+
+	i     *Instance
+	state turtleState
+	queue []turtleState
+	drawn turtleState
+
+	// from is where the move at the head of the queue starts, moved how
+	// far along it the turtle is and plotted how many of its line steps
+	// are drawn
+	from    turtleState
+	moved   float64
+	plotted int
+
+	under *Surface
+	shown image.Rectangle
+}
+
+type turtleState struct {
+	x, y, heading float64
+	pen           bool
+	color         Color
+}
+
+// NewTurtle returns a turtle bound to i.
+func NewTurtle(i *Instance) *Turtle {
+	t := &Turtle{i: i}
+	t.state = t.home(turtleState{pen: true, color: i.CurrentColor})
+	t.drawn = t.state
+	t.from = t.state
+	i.turtles = append(i.turtles, t)
+	return t
+}
+
+func (t *Turtle) home(s turtleState) turtleState {
+	s.x = float64(t.i.Width) / 2
+	s.y = float64(t.i.Height) / 2
+	s.heading = 90
+	return s
+}
+
+// Forward moves the turtle d pixels along its heading, drawing a line when
+// the pen is down.
+func (t *Turtle) Forward(d float64) {
+	sin, cos := math.Sincos(t.state.heading * math.Pi / 180)
+	s := t.state
+	s.x += d * cos
+	s.y -= d * sin
+	t.push(s)
+}
+
+// Back moves the turtle d pixels backwards.
+func (t *Turtle) Back(d float64) {
+	t.Forward(-d)
+}
+
+// Left turns the turtle counterclockwise by a degrees.
+func (t *Turtle) Left(a float64) {
+	s := t.state
+	s.heading = math.Mod(s.heading+a, 360)
+	if s.heading < 0 {
+		s.heading += 360
+	}
+	t.push(s)
+}
+
+// Right turns the turtle clockwise by a degrees.
+func (t *Turtle) Right(a float64) {
+	t.Left(-a)
+}
+
+func (t *Turtle) PenUp() {
+	s := t.state
+	s.pen = false
+	t.push(s)
+}
+
+func (t *Turtle) PenDown() {
+	s := t.state
+	s.pen = true
+	t.push(s)
+}
+
+// SetColor sets the colour of the lines drawn from now on.
+func (t *Turtle) SetColor(c Color) {
+	s := t.state
+	s.color = c
+	t.push(s)
+}
+
+// Home moves the turtle back to the centre of the screen facing up,
+// drawing a line when the pen is down.
+func (t *Turtle) Home() {
+	t.push(t.home(t.state))
+}
+
+// SetPosition moves the turtle to x, y, drawing a line when the pen is
+// down.
+func (t *Turtle) SetPosition(x, y float64) {
+	s := t.state
+	s.x, s.y = x, y
+	t.push(s)
+}
+
+// SetHeading turns the turtle to face heading degrees.
+func (t *Turtle) SetHeading(heading float64) {
+	t.Left(heading - t.state.heading)
+}
+
+func (t *Turtle) Position() (x, y float64) {
+	return t.state.x, t.state.y
+}
+
+func (t *Turtle) Heading() float64 {
+	return t.state.heading
+}
+
+func (t *Turtle) IsPenDown() bool {
+	return t.state.pen
+}
+
+// Done reports whether the turtle has finished drawing its queued
+// commands.
+func (t *Turtle) Done() bool {
+	return len(t.queue) == 0
+}
+
+func (t *Turtle) push(s turtleState) {
+	t.state = s
+	t.queue = append(t.queue, s)
+	if t.Speed <= 0 && t.i.img != nil {
+		t.step(0)
+	}
+}
+
+// step draws up to budget pixels of the queued moves, or all of them when
+// budget is zero.
+func (t *Turtle) step(budget float64) {
+	if budget <= 0 {
+		budget = math.Inf(1)
+	}
+	for len(t.queue) > 0 {
+		next := t.queue[0]
+		from := t.from
+		d := math.Hypot(next.x-from.x, next.y-from.y)
+		if t.moved+budget < d {
+			t.moved += budget
+			k := t.moved / d
+			t.line(from, next, k)
+			t.drawn = next
+			t.drawn.x = from.x + (next.x-from.x)*k
+			t.drawn.y = from.y + (next.y-from.y)*k
+			return
+		}
+		budget -= d - t.moved
+		t.line(from, next, 1)
+		t.drawn, t.from = next, next
+		t.moved, t.plotted = 0, 0
+		t.queue = t.queue[1:]
+	}
+}
+
+// line draws the steps of the line from from to to that lie within the
+// fraction k of its length and were not drawn yet, so that the line is
+// built from the pixels a single DrawLine would give.
+func (t *Turtle) line(from, to turtleState, k float64) {
+	if !to.pen || (from.x == to.x && from.y == to.y) {
+		return
+	}
+	x0, y0 := int(math.Round(from.x)), int(math.Round(from.y))
+	x1, y1 := int(math.Round(to.x)), int(math.Round(to.y))
+	last := int(k * float64(max(abs(x1-x0), abs(y1-y0))))
+
+	s := &t.i.Surface
+	old := s.CurrentColor
+	s.CurrentColor = to.color
+	switch {
+	case !s.thick():
+		s.drawSteps(x0, y0, x1, y1, t.plotted, last)
+	case k >= 1:
+		s.DrawLine(x0, y0, x1, y1)
+	}
+	s.CurrentColor = old
+	t.plotted = last + 1
+	t.i.UpdateScreen = true
+}
+
+// show draws the turtle, saving the pixels underneath for hide.
+func (t *Turtle) show() {
+	if !t.Visible {
+		return
+	}
+	s := &t.i.Surface
+	clip := s.clip
+	s.ResetClip()
+	defer s.SetClip(clip)
+
+	x, y := t.drawn.x, t.drawn.y
+	size := 12
+	if t.Shape != nil {
+		size = int(math.Ceil(math.Hypot(float64(t.Shape.Width), float64(t.Shape.Height))))
+	}
+	r := image.Rect(int(x)-size/2-1, int(y)-size/2-1, int(x)+size/2+2, int(y)+size/2+2).Intersect(s.clip)
+	if t.under == nil || t.under.Bounds().Size() != r.Size() {
+		t.under = NewSurface(r.Dx(), r.Dy())
+	}
+	t.under.Blit(s, r, 0, 0)
+	t.shown = r
+	t.i.UpdateScreen = true
+
+	mode, fill := s.Blend, s.FillStyle
+	s.Blend, s.FillStyle = BlendOver, nil
+	defer func() { s.Blend, s.FillStyle = mode, fill }()
+
+	if t.Shape != nil {
+		w, h := t.Shape.Width, t.Shape.Height
+		s.DrawSpriteEx(t.Shape, int(math.Round(x-float64(w)/2)), int(math.Round(y-float64(h)/2)), SpriteOptions{Angle: t.drawn.heading - 90})
+		return
+	}
+	pt := func(a, l float64) image.Point {
+		sin, cos := math.Sincos((t.drawn.heading + a) * math.Pi / 180)
+		return image.Point{int(math.Round(x + l*cos)), int(math.Round(y - l*sin))}
+	}
+	old := s.CurrentColor
+	s.CurrentColor = t.drawn.color
+	s.DrawFilledPolygon([]image.Point{pt(0, 6), pt(140, 6), pt(220, 6)}, FillEvenOdd)
+	s.CurrentColor = old
+}
+
+// hide restores the pixels under the turtle.
+func (t *Turtle) hide() {
+	if t.shown.Empty() {
+		return
+	}
+	s := &t.i.Surface
+	clip := s.clip
+	s.ResetClip()
+	s.Blit(t.under, image.Rect(0, 0, t.shown.Dx(), t.shown.Dy()), t.shown.Min.X, t.shown.Min.Y)
+	s.SetClip(clip)
+	t.shown = image.Rectangle{}
+	t.i.UpdateScreen = true
+}
@@ -0,0 +1,52 @@
+package graphos
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestTurtlePosition(t *testing.T) {
+	i := New()
+	i.Init()
+	tt := NewTurtle(i)
+	for j := 0; j < 4; j++ {
+		tt.Forward(50)
+		tt.Right(90)
+	}
+	x, y := tt.Position()
+	if math.Abs(x-float64(i.Width)/2) > 1e-9 || math.Abs(y-float64(i.Height)/2) > 1e-9 || tt.Heading() != 90 {
+		t.Fatalf("turtle at %v,%v heading %v, want back home", x, y, tt.Heading())
+	}
+}
+
+// An animated turtle must leave the same pixels as one drawing at once,
+// even under XOR where a pixel drawn twice disappears.
+func TestTurtleAnimation(t *testing.T) {
+	draw := func(speed float64) []byte {
+		i := New()
+		i.ScreenHandler = func(*Instance) error { return nil }
+		i.Init()
+		i.Blend = XorPut
+		tt := NewTurtle(i)
+		tt.Speed = speed
+		tt.SetColor(white)
+		for j := 0; j < 36; j++ {
+			tt.Forward(37)
+			tt.Left(100)
+		}
+		for !tt.Done() {
+			err := i.Step()
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		return bytes.Clone(i.img.Pix)
+	}
+	want := draw(0)
+	for _, speed := range []float64{0.7, 3, 11.3} {
+		if !bytes.Equal(draw(speed), want) {
+			t.Errorf("speed %v draws different pixels", speed)
+		}
+	}
+}